@@ -0,0 +1,120 @@
+package main
+
+import (
+	"Dysec/internal/questionbank"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const commandUsage = `usage:
+  api                                  menjalankan server API
+  api import-questions [flags] FILE    impor soal dari CSV/JSON (FILE "-" untuk stdin)
+  api export-questions [flags]         ekspor soal ke CSV/JSON`
+
+// runCommand menjalankan subcommand CLI dan mengembalikan exit code
+func runCommand(db *gorm.DB, args []string) int {
+	switch args[0] {
+	case "import-questions":
+		return importQuestions(db, args[1:])
+	case "export-questions":
+		return exportQuestions(db, args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+}
+
+func importQuestions(db *gorm.DB, args []string) int {
+	fs := flag.NewFlagSet("import-questions", flag.ContinueOnError)
+	format := fs.String("format", "", "csv atau json (default: dari ekstensi file)")
+	dryRun := fs.Bool("dry-run", false, "validasi dan laporkan tanpa menyimpan")
+	editedBy := fs.Uint("user", 0, "ID admin yang dicatat pada versi soal yang diubah")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	rows, err := questionbank.Read(in, *format)
+	if err != nil {
+		log.Printf("ERROR: Invalid import file: %v", err)
+		return 1
+	}
+	report, err := questionbank.Import(db, rows, *dryRun, *editedBy)
+	if err != nil {
+		log.Printf("ERROR: Could not import questions: %v", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Printf("ERROR: %v", err)
+		return 1
+	}
+	if len(report.Errors) > 0 {
+		log.Printf("Import contains %d invalid rows; nothing was saved", len(report.Errors))
+		return 1
+	}
+	return 0
+}
+
+func exportQuestions(db *gorm.DB, args []string) int {
+	fs := flag.NewFlagSet("export-questions", flag.ContinueOnError)
+	format := fs.String("format", questionbank.FormatCSV, "csv atau json")
+	output := fs.String("o", "-", "file tujuan (\"-\" untuk stdout)")
+	var filter questionbank.Filter
+	fs.StringVar(&filter.Subtest, "subtest", "", "hanya subtes ini")
+	fs.StringVar(&filter.Status, "status", "", "hanya status ini (active atau retired)")
+	fs.StringVar(&filter.Difficulty, "difficulty", "", "hanya tingkat kesulitan ini")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	records, err := questionbank.Export(db, filter)
+	if err != nil {
+		log.Printf("ERROR: Could not export questions: %v", err)
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := questionbank.Write(out, *format, records); err != nil {
+		log.Printf("ERROR: Could not write export: %v", err)
+		return 1
+	}
+	log.Printf("Exported %d questions", len(records))
+	return 0
+}
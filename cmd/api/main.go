@@ -1,11 +1,20 @@
 package main
 
 import (
+	"Dysec/internal/ageband"
 	"Dysec/internal/ai"
+	"Dysec/internal/auth"
 	"Dysec/internal/database"
 	"Dysec/internal/handlers"
 	"Dysec/internal/middleware"
+	"Dysec/internal/prompts"
+	"Dysec/internal/questionbank"
+	"Dysec/internal/ratelimit"
+	"Dysec/internal/scoring"
+	"Dysec/internal/usage"
+	"context"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -32,41 +41,83 @@ func main() {
 		log.Fatalf("Could not connect to the database: %v", err)
 	}
 
-	// 2. Baca Konfigurasi Gemini API Key
-	geminiAPIKey := viper.GetString("GEMINI_API_KEY") // Coba dari env var
-	if geminiAPIKey == "" {
-		geminiAPIKey = viper.GetString("gemini.api_key") // Fallback ke config.yaml
-	}
-	if geminiAPIKey == "" {
-		log.Fatal("Gemini API key not found in config or environment variables")
+	// Subcommand CLI (mis. import-questions) hanya butuh database, tidak menjalankan server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(db, os.Args[1:]))
 	}
 
-	// 3. Inisialisasi AI Service
-	aiService, err := ai.NewService(geminiAPIKey)
+	// 2. Inisialisasi AI Service (gemini, openai, atau fixture sesuai ai.provider)
+	aiService, err := ai.NewGeneratorFromConfig()
 	if err != nil {
 		log.Fatalf("Could not initialize AI service: %v", err)
 	}
 
-	// 4. Inisialisasi Handler
-	h := handlers.New(db, aiService)
+	// 3. Inisialisasi Token Manager (JWT aplikasi)
+	tokens, err := auth.NewTokenManagerFromConfig()
+	if err != nil {
+		log.Fatalf("Could not initialize token manager: %v", err)
+	}
 
-	// 5. Setup Router
-	router := gin.Default()
-	v1 := router.Group("/api/v1")
-	{
-		v1.POST("/auth/google", h.GoogleAuthHandler)
+	// 4. Inisialisasi Verifier ID token (google, jwks, atau static untuk test)
+	verifier, err := auth.NewVerifierFromConfig()
+	if err != nil {
+		log.Fatalf("Could not initialize token verifier: %v", err)
+	}
+
+	// 5. Inisialisasi Rate Limiter (memory atau postgres)
+	limiter, err := ratelimit.NewLimiterFromConfig(db)
+	if err != nil {
+		log.Fatalf("Could not initialize rate limiter: %v", err)
+	}
+
+	// 6. Inisialisasi template prompt (file atau db)
+	promptRenderer, err := prompts.NewRendererFromConfig(db)
+	if err != nil {
+		log.Fatalf("Could not initialize prompt templates: %v", err)
+	}
+
+	// 7. Inisialisasi Scorer (layanan klasifikasi ML, atau stub lokal)
+	scorer, err := scoring.NewScorerFromConfig()
+	if err != nil {
+		log.Fatalf("Could not initialize scoring service: %v", err)
+	}
+
+	// 8. Inisialisasi ledger pemakaian AI (biaya dan budget bulanan)
+	ledger, err := usage.NewLedgerFromConfig(db)
+	if err != nil {
+		log.Fatalf("Could not initialize AI usage ledger: %v", err)
+	}
+
+	// 9. Inisialisasi kelompok usia untuk pemilihan tingkat kesulitan
+	ageBands, err := ageband.NewTableFromConfig()
+	if err != nil {
+		log.Fatalf("Could not load age bands: %v", err)
+	}
 
-		authorized := v1.Group("/")
-		authorized.Use(middleware.GoogleTokenMiddleware(db))
-		{
-			authorized.POST("/tests/start", h.StartSessionHandler)
-			authorized.POST("/tests/:id/submit", h.SubmitTestHandler)
-			authorized.GET("/tests/history", h.TestHistoryHandler)
-			authorized.GET("/users/me", h.UserProfileHandler)
-		}
+	// 10. Inisialisasi Handler
+	h := handlers.New(db, aiService, tokens, verifier, promptRenderer, scorer, ledger, ageBands)
+	if viper.IsSet("tests.live_generation") {
+		h.LiveGeneration = viper.GetBool("tests.live_generation")
+	}
+
+	// 11. Jalankan worker yang menjaga stok bank soal
+	if !viper.IsSet("question_bank.worker.enabled") || viper.GetBool("question_bank.worker.enabled") {
+		questionbank.NewWorkerFromConfig(db, aiService, promptRenderer, ledger).Start(context.Background())
+	}
+
+	// 12. Jalankan analisis soal berkala (statistik per soal dari jawaban tes)
+	if !viper.IsSet("item_analysis.enabled") || viper.GetBool("item_analysis.enabled") {
+		h.ItemStats.Start(context.Background())
+	}
+
+	// 13. Setup Router
+	router := gin.Default()
+	if err := middleware.TrustProxies(router, viper.GetStringSlice("server.trusted_proxies")); err != nil {
+		log.Fatalf("Invalid server.trusted_proxies: %v", err)
 	}
+	registerRoutes(router, &h, db, tokens, limiter)
 
-	// 6. Jalankan Server
+	// 14. Jalankan Server
 	log.Println("Starting server on port 8080...")
 	if err := router.Run(":8080"); err != nil {
 		log.Fatal("Failed to start server: ", err)
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"Dysec/internal/auth"
+	"Dysec/internal/handlers"
+	"Dysec/internal/middleware"
+	"Dysec/internal/models"
+	"Dysec/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// registerRoutes memasang semua route /api/v1 pada router
+func registerRoutes(router *gin.Engine, h *handlers.Handler, db *gorm.DB, tokens *auth.TokenManager, limiter *ratelimit.Limiter) {
+	v1 := router.Group("/api/v1")
+	{
+		v1.POST("/auth/google", middleware.RateLimit(limiter, "auth"), h.GoogleAuthHandler)
+		v1.POST("/auth/refresh", middleware.RateLimit(limiter, "auth"), h.RefreshTokenHandler)
+
+		authorized := v1.Group("/")
+		authorized.Use(middleware.JWTMiddleware(tokens, db))
+		{
+			authorized.POST("/tests/start", middleware.RateLimit(limiter, "tests_start"), h.StartSessionHandler)
+			authorized.POST("/tests/:id/submit", middleware.RateLimit(limiter, "tests_submit"), h.SubmitTestHandler)
+			authorized.GET("/tests/history", h.TestHistoryHandler)
+			authorized.GET("/tests/:id/next-item", h.NextItemHandler)
+			authorized.POST("/tests/:id/responses", middleware.RateLimit(limiter, "tests_submit"), h.SubmitResponseHandler)
+			authorized.GET("/users/me", h.UserProfileHandler)
+			authorized.POST("/auth/logout", h.LogoutHandler)
+			authorized.GET("/users/me/sessions", h.ListSessionsHandler)
+			authorized.DELETE("/users/me/sessions/:id", h.RevokeSessionHandler)
+			authorized.GET("/users/me/export", h.ExportDataHandler)
+			authorized.DELETE("/users/me", h.DeleteAccountHandler)
+
+			authorized.GET("/profiles", h.ListProfilesHandler)
+			authorized.POST("/profiles", h.CreateProfileHandler)
+			authorized.GET("/profiles/:id", h.GetProfileHandler)
+			authorized.PUT("/profiles/:id", h.UpdateProfileHandler)
+			authorized.DELETE("/profiles/:id", h.DeleteProfileHandler)
+		}
+
+		admin := v1.Group("/admin")
+		admin.Use(middleware.JWTMiddleware(tokens, db), middleware.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/users", h.ListUsersHandler)
+			admin.PUT("/users/:id/role", h.UpdateUserRoleHandler)
+			admin.PUT("/users/:id/organization", h.UpdateUserOrganizationHandler)
+
+			admin.GET("/organizations", h.ListOrganizationsHandler)
+			admin.POST("/organizations", h.CreateOrganizationHandler)
+			admin.GET("/organizations/:id/api-keys", h.ListAPIKeysHandler)
+			admin.POST("/organizations/:id/api-keys", h.CreateAPIKeyHandler)
+			admin.DELETE("/api-keys/:id", h.RevokeAPIKeyHandler)
+
+			admin.GET("/ai/status", h.AIStatusHandler)
+			admin.GET("/ai/usage", h.AIUsageHandler)
+			admin.GET("/users/:id/ai-budget", h.UserAIBudgetHandler)
+
+			admin.GET("/prompts/:template_id", h.ListPromptVersionsHandler)
+			admin.POST("/prompts/:template_id", h.CreatePromptVersionHandler)
+
+			admin.GET("/questions", h.ListQuestionsHandler)
+			admin.POST("/questions", h.CreateQuestionHandler)
+			admin.POST("/questions/bulk-status", h.BulkQuestionStatusHandler)
+			admin.POST("/questions/import", h.ImportQuestionsHandler)
+			admin.GET("/questions/export", h.ExportQuestionsHandler)
+			admin.GET("/questions/stats", h.ListItemStatsHandler)
+			admin.POST("/questions/stats/refresh", h.RefreshItemStatsHandler)
+			admin.GET("/questions/:id", h.GetQuestionHandler)
+			admin.PUT("/questions/:id", h.UpdateQuestionHandler)
+			admin.DELETE("/questions/:id", h.RetireQuestionHandler)
+			admin.GET("/questions/:id/preview", h.PreviewQuestionHandler)
+		}
+
+		// Integrasi server-to-server institusi memakai API key, bukan login Google
+		integrations := v1.Group("/integrations")
+		integrations.Use(middleware.APIKeyMiddleware(db))
+		{
+			integrations.GET("/tests", middleware.RequireScope(models.ScopeTestsRead), h.IntegrationTestsHandler)
+			integrations.GET("/results", middleware.RequireScope(models.ScopeResultsRead), h.IntegrationResultsHandler)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// scoring-stub menjalankan tiruan layanan klasifikasi ML untuk development dan test lokal.
+//
+//	go run ./cmd/scoring-stub -addr :8090 -token secret
+//
+// lalu set scoring.url ke http://localhost:8090/predict dan SCORING_API_TOKEN=secret.
+package main
+
+import (
+	"Dysec/internal/scoring"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	token := flag.String("token", os.Getenv("SCORING_API_TOKEN"), "bearer token required from clients (empty = no auth)")
+	flag.Parse()
+
+	mux := http.NewServeMux()
+	mux.Handle("/predict", scoring.StubHandler(*token))
+
+	log.Printf("Scoring stub listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
@@ -0,0 +1,86 @@
+package ageband
+
+import (
+	"Dysec/internal/models"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+// Band adalah kelompok usia (inklusif) beserta tingkat kesulitan soal untuk kelompok tersebut
+type Band struct {
+	ID         string `mapstructure:"id" json:"id"`
+	MinAge     int    `mapstructure:"min_age" json:"min_age"`
+	MaxAge     int    `mapstructure:"max_age" json:"max_age"`
+	Difficulty string `mapstructure:"difficulty" json:"difficulty"`
+}
+
+// DefaultBands mengikuti jenjang SD: kelas 1-2, kelas 3-4, dan kelas 5 ke atas
+var DefaultBands = []Band{
+	{ID: "5-7", MinAge: 5, MaxAge: 7, Difficulty: models.DifficultyEasy},
+	{ID: "8-9", MinAge: 8, MaxAge: 9, Difficulty: models.DifficultyMedium},
+	{ID: "10-15", MinAge: 10, MaxAge: 15, Difficulty: models.DifficultyHard},
+}
+
+// gradeAgeOffset adalah selisih usia umum terhadap kelas SD (kelas 1 = 6 tahun)
+const gradeAgeOffset = 5
+
+var gradePattern = regexp.MustCompile(`\d+`)
+
+// Table adalah daftar kelompok usia yang dipakai saat menyusun tes
+type Table []Band
+
+// NewTableFromConfig membaca tests.age_bands; tanpa konfigurasi memakai DefaultBands
+func NewTableFromConfig() (Table, error) {
+	var bands []Band
+	if err := viper.UnmarshalKey("tests.age_bands", &bands); err != nil {
+		return nil, fmt.Errorf("invalid tests.age_bands: %w", err)
+	}
+	if len(bands) == 0 {
+		return DefaultBands, nil
+	}
+	for _, b := range bands {
+		if b.ID == "" || b.MinAge > b.MaxAge || !models.IsValidDifficulty(b.Difficulty) {
+			return nil, fmt.Errorf("invalid tests.age_bands entry %+v", b)
+		}
+	}
+	return bands, nil
+}
+
+// ForAge mencari kelompok untuk usia dalam tahun penuh
+func (t Table) ForAge(age int) (Band, bool) {
+	for _, b := range t {
+		if age >= b.MinAge && age <= b.MaxAge {
+			return b, true
+		}
+	}
+	return Band{}, false
+}
+
+// ForGrade memperkirakan usia dari kelas (mis. "3", "Kelas 3", "3 SD") lalu mencari kelompoknya
+func (t Table) ForGrade(grade string) (Band, bool) {
+	digits := gradePattern.FindString(grade)
+	if digits == "" {
+		return Band{}, false
+	}
+	g, err := strconv.Atoi(digits)
+	if err != nil {
+		return Band{}, false
+	}
+	return t.ForAge(g + gradeAgeOffset)
+}
+
+// Resolve memilih kelompok dari usia bila diketahui, dengan kelas sebagai cadangan
+func (t Table) Resolve(age *int, grade string) (Band, bool) {
+	if age != nil {
+		if b, ok := t.ForAge(*age); ok {
+			return b, true
+		}
+	}
+	if grade != "" {
+		return t.ForGrade(grade)
+	}
+	return Band{}, false
+}
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultGeminiModel   = "gemini-1.5-flash"
+	defaultTimeout       = 30 * time.Second
+	defaultRequestBudget = 45 * time.Second
+)
+
+// SafetySetting memetakan kategori bahaya Gemini ke ambang blokirnya,
+// mis. HARM_CATEGORY_HARASSMENT -> BLOCK_LOW_AND_ABOVE
+type SafetySetting struct {
+	Category  string `mapstructure:"category"`
+	Threshold string `mapstructure:"threshold"`
+}
+
+// GenerationConfig adalah parameter pemanggilan model yang bisa diatur per environment
+type GenerationConfig struct {
+	Model           string
+	Temperature     *float32
+	TopP            *float32
+	MaxOutputTokens int32
+	Timeout         time.Duration
+	SafetySettings  []SafetySetting
+}
+
+// GenerationConfigFromConfig membaca ai.model, ai.temperature, ai.top_p, ai.max_output_tokens,
+// ai.timeout dan ai.safety_settings. Parameter yang tidak diisi memakai default provider; model default
+// dipilih per provider di newProviderFromConfig.
+func GenerationConfigFromConfig() GenerationConfig {
+	cfg := GenerationConfig{
+		Model:           viper.GetString("ai.model"),
+		MaxOutputTokens: viper.GetInt32("ai.max_output_tokens"),
+		Timeout:         viper.GetDuration("ai.timeout"),
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if viper.IsSet("ai.temperature") {
+		v := float32(viper.GetFloat64("ai.temperature"))
+		cfg.Temperature = &v
+	}
+	if viper.IsSet("ai.top_p") {
+		v := float32(viper.GetFloat64("ai.top_p"))
+		cfg.TopP = &v
+	}
+	_ = viper.UnmarshalKey("ai.safety_settings", &cfg.SafetySettings)
+	return cfg
+}
+
+// RequestBudgetFromConfig membaca ai.request_budget: batas waktu total pembuatan tes oleh AI di jalur
+// request, termasuk retry dan perbaikan output. Setelah budget habis tes disusun tanpa AI.
+func RequestBudgetFromConfig() time.Duration {
+	budget := viper.GetDuration("ai.request_budget")
+	if budget <= 0 {
+		budget = defaultRequestBudget
+	}
+	return budget
+}
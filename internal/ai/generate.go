@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"Dysec/internal/testset"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const defaultMaxRepairAttempts = 2
+
+// GenerateTest meminta satu set tes ke generator lalu memvalidasinya secara ketat, termasuk
+// menghitung ulang kunci jawaban (testset.Verify). Jika output tidak valid, AI diminta
+// memperbaiki outputnya sendiri sampai ai.max_repair_attempts kali.
+// usages berisi pemakaian setiap panggilan, termasuk percobaan yang gagal dan juga saat err != nil,
+// agar semua token yang terpakai tetap bisa dicatat.
+func GenerateTest(ctx context.Context, g Generator, prompt string, counts map[string]int) (*testset.Generated, []Usage, error) {
+	maxRepairs := defaultMaxRepairAttempts
+	if viper.IsSet("ai.max_repair_attempts") {
+		maxRepairs = viper.GetInt("ai.max_repair_attempts")
+	}
+
+	var usages []Usage
+	currentPrompt := prompt
+	for attempt := 0; ; attempt++ {
+		completion, err := g.GenerateTestFromPrompt(ctx, currentPrompt)
+		if err != nil {
+			return nil, append(usages, UsagesOf(err)...), err
+		}
+		usages = append(usages, completion.Failed...)
+		usages = append(usages, completion.Usage)
+		raw := completion.Text
+
+		generated, err := testset.Parse(raw)
+		if err == nil {
+			err = generated.Validate(counts)
+		}
+		if err == nil {
+			// Kunci jawaban dihitung ulang sebelum soal dipakai atau disimpan ke bank soal
+			var fixes []string
+			fixes, err = generated.Verify()
+			for _, fix := range fixes {
+				log.Printf("WARNING: AI answer key fixed: %s", fix)
+			}
+		}
+		if err == nil {
+			return generated, usages, nil
+		}
+
+		var verr *testset.ValidationError
+		if !errors.As(err, &verr) {
+			return nil, usages, err
+		}
+		if attempt >= maxRepairs {
+			return nil, usages, fmt.Errorf("AI output still invalid after %d repair attempts: %w", attempt, err)
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, usages, fmt.Errorf("no time left to repair invalid AI output (%v): %w", err, ctxErr)
+		}
+		log.Printf("WARNING: AI output invalid (attempt %d): %v. Asking the model to repair it.", attempt+1, err)
+		currentPrompt = repairPrompt(prompt, raw, verr)
+	}
+}
+
+func repairPrompt(original, previous string, verr *testset.ValidationError) string {
+	var b strings.Builder
+	b.WriteString(original)
+	b.WriteString("\n\nOutput kamu sebelumnya:\n")
+	b.WriteString(previous)
+	b.WriteString("\n\nOutput tersebut TIDAK VALID karena:\n")
+	for _, problem := range verr.Problems {
+		b.WriteString("- ")
+		b.WriteString(problem)
+		b.WriteString("\n")
+	}
+	b.WriteString("\nPerbaiki semua masalah di atas dan kirim ulang JSON lengkap yang sesuai schema.")
+	return b.String()
+}
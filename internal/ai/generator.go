@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Generator adalah penyedia teks AI yang dipakai handler. Implementasinya: Service (Gemini),
+// OpenAIService (API kompatibel OpenAI), dan FixtureService (respons lokal deterministik).
+// Completion membawa teks beserta pemakaian token untuk pencatatan biaya.
+type Generator interface {
+	GenerateTestFromPrompt(ctx context.Context, prompt string) (*Completion, error)
+}
+
+// StatsReporter diimplementasikan Generator yang bisa melaporkan state circuit breaker
+type StatsReporter interface {
+	Stats() BreakerStats
+}
+
+// NewGeneratorFromConfig memilih provider berdasarkan ai.provider: gemini (default), openai, atau fixture,
+// lalu membungkusnya dengan retry dan circuit breaker
+func NewGeneratorFromConfig() (Generator, error) {
+	base, err := newProviderFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewResilientGeneratorFromConfig(base), nil
+}
+
+func newProviderFromConfig() (Generator, error) {
+	cfg := GenerationConfigFromConfig()
+
+	switch provider := strings.ToLower(viper.GetString("ai.provider")); provider {
+	case "", "gemini":
+		apiKey := viper.GetString("GEMINI_API_KEY") // Coba dari env var
+		if apiKey == "" {
+			apiKey = viper.GetString("gemini.api_key") // Fallback ke config.yaml
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("gemini API key not found in config or environment variables")
+		}
+		if cfg.Model == "" {
+			cfg.Model = defaultGeminiModel
+		}
+		return NewService(apiKey, cfg)
+	case "openai":
+		apiKey := viper.GetString("OPENAI_API_KEY")
+		if apiKey == "" {
+			apiKey = viper.GetString("ai.openai.api_key")
+		}
+		if model := viper.GetString("ai.openai.model"); model != "" {
+			cfg.Model = model
+		}
+		return NewOpenAIService(viper.GetString("ai.openai.base_url"), apiKey, cfg)
+	case "fixture":
+		return NewFixtureService(viper.GetString("ai.fixture.path"))
+	default:
+		return nil, fmt.Errorf("unknown ai.provider %q", provider)
+	}
+}
@@ -0,0 +1,163 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIService memanggil endpoint /chat/completions yang kompatibel dengan OpenAI
+// (OpenAI, Azure OpenAI, vLLM, Ollama, dsb.)
+type OpenAIService struct {
+	BaseURL string
+	APIKey  string
+	Config  GenerationConfig
+	client  *http.Client
+}
+
+func NewOpenAIService(baseURL, apiKey string, cfg GenerationConfig) (*OpenAIService, error) {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("ai.model is required for the openai provider")
+	}
+
+	log.Printf("AI Service Initialized Successfully (openai-compatible, %s, %s)", baseURL, cfg.Model)
+	return &OpenAIService{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Config:  cfg,
+		client:  &http.Client{},
+	}, nil
+}
+
+// StatusError adalah respons non-200 dari provider HTTP
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("status %d", e.StatusCode)
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model          string                 `json:"model"`
+	Messages       []openAIMessage        `json:"messages"`
+	Temperature    *float32               `json:"temperature,omitempty"`
+	TopP           *float32               `json:"top_p,omitempty"`
+	MaxTokens      int32                  `json:"max_tokens,omitempty"`
+	ResponseFormat map[string]interface{} `json:"response_format,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Model string `json:"model"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (s *OpenAIService) GenerateTestFromPrompt(ctx context.Context, prompt string) (*Completion, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Config.Timeout)
+	defer cancel()
+
+	body, err := json.Marshal(openAIChatRequest{
+		Model:       s.Config.Model,
+		Messages:    []openAIMessage{{Role: "user", Content: prompt}},
+		Temperature: s.Config.Temperature,
+		TopP:        s.Config.TopP,
+		MaxTokens:   s.Config.MaxOutputTokens,
+		ResponseFormat: map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "dysec_test",
+				"strict": true,
+				"schema": jsonSchema(TestSchema()),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghasilkan konten dari AI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca respons AI: %w", err)
+	}
+
+	var parsed openAIChatResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("gagal menghasilkan konten dari AI: %w", &StatusError{StatusCode: resp.StatusCode})
+		}
+		return nil, fmt.Errorf("respons AI tidak valid: %w", err)
+	}
+
+	// Pemakaian dicatat sebelum respons diperiksa: respons yang gagal atau kosong tetap bisa memakai token
+	usage := Usage{Provider: "openai", Model: s.Config.Model, Latency: time.Since(start)}
+	if parsed.Model != "" {
+		usage.Model = parsed.Model
+	}
+	var spent []Usage
+	if parsed.Usage != nil {
+		usage.PromptTokens = parsed.Usage.PromptTokens
+		usage.CompletionTokens = parsed.Usage.CompletionTokens
+		usage.TotalTokens = parsed.Usage.TotalTokens
+		spent = append(spent, usage)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
+		if parsed.Error != nil {
+			statusErr.Message = parsed.Error.Message
+		}
+		return nil, withUsage(fmt.Errorf("gagal menghasilkan konten dari AI: %w", statusErr), spent...)
+	}
+
+	if len(parsed.Choices) == 0 || parsed.Choices[0].Message.Content == "" {
+		return nil, withUsage(fmt.Errorf("tidak ada konten teks yang ditemukan dalam respons AI"), spent...)
+	}
+
+	return &Completion{Text: parsed.Choices[0].Message.Content, Usage: usage}, nil
+}
@@ -0,0 +1,278 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/genai"
+)
+
+// ErrCircuitOpen dikembalikan tanpa memanggil provider selama circuit breaker terbuka
+var ErrCircuitOpen = errors.New("ai circuit breaker is open")
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// BreakerStats adalah snapshot circuit breaker untuk monitoring
+type BreakerStats struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	TripCount           int        `json:"trip_count"`
+	LastTripAt          *time.Time `json:"last_trip_at"`
+	OpenUntil           *time.Time `json:"open_until"`
+	TotalCalls          int        `json:"total_calls"`
+	TotalFailures       int        `json:"total_failures"`
+	RejectedCalls       int        `json:"rejected_calls"`
+}
+
+// CircuitBreaker membuka sirkuit setelah FailureThreshold kegagalan berturut-turut,
+// lalu mengizinkan satu panggilan percobaan (half-open) setelah OpenTimeout berlalu
+type CircuitBreaker struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	mu        sync.Mutex
+	stats     BreakerStats
+	openUntil time.Time
+	probing   bool
+}
+
+func NewCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: threshold,
+		OpenTimeout:      openTimeout,
+		stats:            BreakerStats{State: BreakerClosed},
+	}
+}
+
+// allow memutuskan apakah panggilan boleh diteruskan ke provider
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.stats.State {
+	case BreakerOpen:
+		if time.Now().Before(b.openUntil) {
+			b.stats.RejectedCalls++
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		// Hanya satu panggilan percobaan dalam keadaan half-open
+		if b.probing {
+			b.stats.RejectedCalls++
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.TotalCalls++
+	b.probing = false
+	if err == nil {
+		b.stats.ConsecutiveFailures = 0
+		if b.stats.State != BreakerClosed {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	b.stats.TotalFailures++
+	b.stats.ConsecutiveFailures++
+	if b.stats.State == BreakerHalfOpen || b.stats.ConsecutiveFailures >= b.FailureThreshold {
+		now := time.Now()
+		b.openUntil = now.Add(b.OpenTimeout)
+		b.stats.TripCount++
+		b.stats.LastTripAt = &now
+		b.setState(BreakerOpen)
+		log.Printf("WARNING: AI circuit breaker tripped (trip #%d) after %d consecutive failures. Open until %s. Last error: %v",
+			b.stats.TripCount, b.stats.ConsecutiveFailures, b.openUntil.Format(time.RFC3339), err)
+	}
+}
+
+// release melepas slot percobaan half-open tanpa mencatat hasil (mis. request dibatalkan klien)
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// setState harus dipanggil dengan b.mu terkunci
+func (b *CircuitBreaker) setState(state string) {
+	if b.stats.State != state {
+		log.Printf("INFO: AI circuit breaker state changed: %s -> %s", b.stats.State, state)
+	}
+	b.stats.State = state
+}
+
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	if stats.State == BreakerOpen {
+		openUntil := b.openUntil
+		stats.OpenUntil = &openUntil
+	}
+	return stats
+}
+
+// ResilientGenerator membungkus Generator dengan retry (exponential backoff + jitter)
+// untuk error sementara, dan circuit breaker agar kegagalan beruntun langsung ke fallback.
+// AttemptTimeout adalah batas waktu satu panggilan provider (ai.timeout); retry hanya dilakukan
+// jika sisa deadline context masih cukup untuk jeda backoff ditambah satu panggilan penuh.
+type ResilientGenerator struct {
+	Generator
+	Retry          RetryPolicy
+	Breaker        *CircuitBreaker
+	AttemptTimeout time.Duration
+}
+
+// NewResilientGeneratorFromConfig membaca ai.retry.* dan ai.circuit_breaker.*
+func NewResilientGeneratorFromConfig(g Generator) *ResilientGenerator {
+	retry := RetryPolicy{
+		MaxAttempts: viper.GetInt("ai.retry.max_attempts"),
+		BaseDelay:   viper.GetDuration("ai.retry.base_delay"),
+		MaxDelay:    viper.GetDuration("ai.retry.max_delay"),
+	}
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 3
+	}
+	if retry.BaseDelay <= 0 {
+		retry.BaseDelay = 500 * time.Millisecond
+	}
+	if retry.MaxDelay <= 0 {
+		retry.MaxDelay = 5 * time.Second
+	}
+
+	threshold := viper.GetInt("ai.circuit_breaker.failure_threshold")
+	if threshold <= 0 {
+		threshold = 5
+	}
+	openTimeout := viper.GetDuration("ai.circuit_breaker.open_timeout")
+	if openTimeout <= 0 {
+		openTimeout = 30 * time.Second
+	}
+
+	return &ResilientGenerator{
+		Generator:      g,
+		Retry:          retry,
+		Breaker:        NewCircuitBreaker(threshold, openTimeout),
+		AttemptTimeout: GenerationConfigFromConfig().Timeout,
+	}
+}
+
+func (r *ResilientGenerator) GenerateTestFromPrompt(ctx context.Context, prompt string) (*Completion, error) {
+	if !r.Breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	// spent mengumpulkan pemakaian token percobaan yang gagal agar ikut tercatat ke budget
+	var spent []Usage
+	var lastErr error
+	for attempt := 0; attempt < r.Retry.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := r.backoff(attempt)
+			if !r.fits(ctx, delay) {
+				log.Printf("WARNING: AI call failed (attempt %d/%d): %v. Not enough request budget left to retry.", attempt, r.Retry.MaxAttempts, lastErr)
+				break
+			}
+			log.Printf("WARNING: AI call failed (attempt %d/%d): %v. Retrying in %s.", attempt, r.Retry.MaxAttempts, lastErr, delay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				r.Breaker.release()
+				return nil, withUsage(ctx.Err(), spent...)
+			}
+		}
+
+		completion, err := r.Generator.GenerateTestFromPrompt(ctx, prompt)
+		if err == nil {
+			r.Breaker.record(nil)
+			completion.Failed = append(spent, completion.Failed...)
+			return completion, nil
+		}
+		spent = append(spent, UsagesOf(err)...)
+		lastErr = err
+		if !isTransient(ctx, err) {
+			break
+		}
+	}
+
+	// Pembatalan oleh klien bukan kesalahan provider, jadi tidak dihitung ke breaker. Budget request
+	// yang habis saat menunggu provider tetap dihitung: provider terlalu lambat untuk jalur request.
+	if errors.Is(ctx.Err(), context.Canceled) {
+		r.Breaker.release()
+		return nil, withUsage(lastErr, spent...)
+	}
+	r.Breaker.record(lastErr)
+	return nil, withUsage(lastErr, spent...)
+}
+
+// fits memeriksa apakah sisa deadline ctx cukup untuk jeda delay ditambah satu panggilan penuh
+func (r *ResilientGenerator) fits(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) >= delay+r.AttemptTimeout
+}
+
+// Stats mengekspos state circuit breaker untuk endpoint monitoring
+func (r *ResilientGenerator) Stats() BreakerStats {
+	return r.Breaker.Stats()
+}
+
+// backoff menghitung jeda "full jitter": acak antara 0 dan min(MaxDelay, BaseDelay * 2^attempt)
+func (r *ResilientGenerator) backoff(attempt int) time.Duration {
+	ceiling := r.Retry.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.Retry.MaxDelay {
+		ceiling = r.Retry.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// isTransient menentukan apakah error layak dicoba ulang (jaringan, 429, 5xx). Timeout per panggilan
+// tidak dicoba ulang: provider yang lambat hanya akan menghabiskan satu timeout penuh lagi, jadi
+// kegagalannya langsung dihitung ke circuit breaker.
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	code := 0
+	var apiErr genai.APIError
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &apiErr):
+		code = apiErr.Code
+	case errors.As(err, &statusErr):
+		code = statusErr.StatusCode
+	}
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// scriptedGenerator mengembalikan error berikutnya dari errs setiap dipanggil, lalu sukses
+type scriptedGenerator struct {
+	errs  []error
+	calls int
+}
+
+func (g *scriptedGenerator) GenerateTestFromPrompt(ctx context.Context, prompt string) (*Completion, error) {
+	g.calls++
+	if g.calls <= len(g.errs) {
+		return nil, g.errs[g.calls-1]
+	}
+	return &Completion{Text: "{}"}, nil
+}
+
+func TestResilientGeneratorRetries(t *testing.T) {
+	unavailable := &StatusError{StatusCode: http.StatusServiceUnavailable}
+	timeout := fmt.Errorf("gemini: %w", context.DeadlineExceeded)
+
+	tests := []struct {
+		name         string
+		errs         []error
+		budget       time.Duration
+		wantCalls    int
+		wantErr      bool
+		wantFailures int
+	}{
+		{"success", nil, 0, 1, false, 0},
+		{"transient error is retried", []error{unavailable}, 0, 2, false, 0},
+		{"gives up after max attempts", []error{unavailable, unavailable, unavailable}, 0, 3, true, 1},
+		{"client error is not retried", []error{&StatusError{StatusCode: http.StatusBadRequest}}, 0, 1, true, 1},
+		{"per-call timeout is not retried", []error{timeout}, 0, 1, true, 1},
+		{"retry must fit in the request budget", []error{unavailable}, 500 * time.Millisecond, 1, true, 1},
+		{"retry within the request budget", []error{unavailable}, 5 * time.Second, 2, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &scriptedGenerator{errs: tt.errs}
+			r := &ResilientGenerator{
+				Generator:      g,
+				Retry:          RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+				Breaker:        NewCircuitBreaker(5, time.Minute),
+				AttemptTimeout: time.Second,
+			}
+			ctx := context.Background()
+			if tt.budget > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.budget)
+				defer cancel()
+			}
+
+			_, err := r.GenerateTestFromPrompt(ctx, "prompt")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if g.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", g.calls, tt.wantCalls)
+			}
+			if got := r.Breaker.Stats().ConsecutiveFailures; got != tt.wantFailures {
+				t.Errorf("breaker failures = %d, want %d", got, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestResilientGeneratorBreaker(t *testing.T) {
+	r := &ResilientGenerator{
+		Generator: &scriptedGenerator{errs: []error{context.DeadlineExceeded, context.DeadlineExceeded}},
+		Retry:     RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Breaker:   NewCircuitBreaker(2, time.Minute),
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.GenerateTestFromPrompt(context.Background(), "prompt"); err == nil {
+			t.Fatalf("call %d: expected timeout", i)
+		}
+	}
+	if _, err := r.GenerateTestFromPrompt(context.Background(), "prompt"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error after two timeouts = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestResilientGeneratorClientCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &ResilientGenerator{
+		Generator: &scriptedGenerator{errs: []error{context.Canceled}},
+		Retry:     RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Breaker:   NewCircuitBreaker(1, time.Minute),
+	}
+	if _, err := r.GenerateTestFromPrompt(ctx, "prompt"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if stats := r.Breaker.Stats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 {
+		t.Fatalf("breaker = %+v, want closed without failures after a client cancel", stats)
+	}
+}
+
+func TestResilientGeneratorCarriesFailedUsage(t *testing.T) {
+	blocked := withUsage(errors.New("empty response"), Usage{Provider: "gemini", TotalTokens: 100})
+	unavailable := withUsage(&StatusError{StatusCode: http.StatusServiceUnavailable}, Usage{Provider: "gemini", TotalTokens: 10})
+
+	tests := []struct {
+		name       string
+		errs       []error
+		wantTokens int
+		wantErr    bool
+	}{
+		{"failed attempts before a success", []error{unavailable, unavailable}, 20, false},
+		{"failed attempts after giving up", []error{unavailable, unavailable, unavailable}, 30, true},
+		{"non-retryable failure", []error{blocked}, 100, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ResilientGenerator{
+				Generator: &scriptedGenerator{errs: tt.errs},
+				Retry:     RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+				Breaker:   NewCircuitBreaker(5, time.Minute),
+			}
+			completion, err := r.GenerateTestFromPrompt(context.Background(), "prompt")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			usages := UsagesOf(err)
+			if completion != nil {
+				usages = completion.Failed
+			}
+			tokens := 0
+			for _, u := range usages {
+				tokens += u.TotalTokens
+			}
+			if tokens != tt.wantTokens {
+				t.Errorf("failed usage tokens = %d, want %d", tokens, tt.wantTokens)
+			}
+		})
+	}
+
+	// Status code tetap terbaca di balik UsageError sehingga keputusan retry tidak berubah
+	var statusErr *StatusError
+	if !errors.As(unavailable, &statusErr) || !isTransient(context.Background(), unavailable) {
+		t.Error("UsageError hides the wrapped StatusError")
+	}
+}
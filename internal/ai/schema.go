@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"sort"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// TestSchema adalah response schema untuk testset.Generated, dipakai agar AI hanya mengembalikan JSON yang valid
+func TestSchema() *genai.Schema {
+	str := func(desc string) *genai.Schema {
+		return &genai.Schema{Type: genai.TypeString, Description: desc}
+	}
+	integer := func(desc string) *genai.Schema {
+		return &genai.Schema{Type: genai.TypeInteger, Description: desc}
+	}
+	item := func(props map[string]*genai.Schema) *genai.Schema {
+		props["question_id"] = str("ID unik soal di dalam tes ini")
+		props["type"] = str("Jenis input: text_input atau choice")
+		props["text"] = str("Teks soal yang ditampilkan ke anak")
+		props["answer"] = str("Jawaban benar, berupa angka dalam bentuk string")
+		required := make([]string, 0, len(props))
+		for name := range props {
+			required = append(required, name)
+		}
+		sort.Strings(required)
+		return &genai.Schema{
+			Type:  genai.TypeArray,
+			Items: &genai.Schema{Type: genai.TypeObject, Properties: props, Required: required},
+		}
+	}
+	arithmetic := func(operator string) *genai.Schema {
+		return item(map[string]*genai.Schema{
+			"operands": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeInteger}},
+			"operator": {Type: genai.TypeString, Enum: []string{operator}},
+		})
+	}
+
+	return &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"dot": item(map[string]*genai.Schema{
+				"dot_count": integer("Jumlah titik yang ditampilkan"),
+			}),
+			"stroop": item(map[string]*genai.Schema{
+				"left":       integer("Angka di kiri"),
+				"right":      integer("Angka di kanan"),
+				"left_size":  integer("Ukuran tampilan angka kiri (1-3)"),
+				"right_size": integer("Ukuran tampilan angka kanan (1-3)"),
+			}),
+			"addition":       arithmetic("+"),
+			"multiplication": arithmetic("x"),
+			"substitution": item(map[string]*genai.Schema{
+				"legend": {
+					Type: genai.TypeArray,
+					Items: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"symbol": str("Simbol"),
+							"digit":  integer("Angka pasangan simbol"),
+						},
+						Required: []string{"digit", "symbol"},
+					},
+				},
+				"symbol": str("Simbol yang ditanyakan, harus ada di legend"),
+			}),
+		},
+		Required: []string{"addition", "dot", "multiplication", "stroop", "substitution"},
+	}
+}
+
+// jsonSchema mengubah genai.Schema menjadi JSON Schema standar untuk provider lain (OpenAI)
+func jsonSchema(s *genai.Schema) map[string]interface{} {
+	out := map[string]interface{}{"type": strings.ToLower(string(s.Type))}
+	if s.Description != "" {
+		out["description"] = s.Description
+	}
+	if len(s.Enum) > 0 {
+		out["enum"] = s.Enum
+	}
+	if s.Items != nil {
+		out["items"] = jsonSchema(s.Items)
+	}
+	if len(s.Properties) > 0 {
+		props := map[string]interface{}{}
+		for name, p := range s.Properties {
+			props[name] = jsonSchema(p)
+		}
+		out["properties"] = props
+		out["required"] = s.Required
+		out["additionalProperties"] = false
+	}
+	return out
+}
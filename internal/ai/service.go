@@ -4,15 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"google.golang.org/genai"
 )
 
+// Service adalah Generator berbasis Google Gemini
 type Service struct {
 	Client *genai.Client
+	Config GenerationConfig
 }
 
-func NewService(apiKey string) (*Service, error) {
+func NewService(apiKey string, cfg GenerationConfig) (*Service, error) {
 	ctx := context.Background()
 
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
@@ -22,27 +25,49 @@ func NewService(apiKey string) (*Service, error) {
 		return nil, fmt.Errorf("gagal membuat client genai: %w", err)
 	}
 
-	log.Println("AI Service Initialized Successfully")
-	return &Service{Client: client}, nil
+	log.Printf("AI Service Initialized Successfully (gemini, %s)", cfg.Model)
+	return &Service{Client: client, Config: cfg}, nil
 }
 
-func (s *Service) GenerateTestFromPrompt(prompt string) (string, error) {
-	ctx := context.Background()
+func (s *Service) GenerateTestFromPrompt(ctx context.Context, prompt string) (*Completion, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Config.Timeout)
+	defer cancel()
+
+	// Minta JSON yang dibatasi schema agar tidak perlu menebak posisi { dan } di teks bebas
+	config := &genai.GenerateContentConfig{
+		ResponseMIMEType: "application/json",
+		ResponseSchema:   TestSchema(),
+		Temperature:      s.Config.Temperature,
+		TopP:             s.Config.TopP,
+		MaxOutputTokens:  s.Config.MaxOutputTokens,
+	}
+	for _, setting := range s.Config.SafetySettings {
+		config.SafetySettings = append(config.SafetySettings, &genai.SafetySetting{
+			Category:  genai.HarmCategory(setting.Category),
+			Threshold: genai.HarmBlockThreshold(setting.Threshold),
+		})
+	}
 
-	result, err := s.Client.Models.GenerateContent(
-		ctx,
-		"gemini-1.5-flash",
-		genai.Text(prompt),
-		nil,
-	)
+	start := time.Now()
+	result, err := s.Client.Models.GenerateContent(ctx, s.Config.Model, genai.Text(prompt), config)
 	if err != nil {
-		return "", fmt.Errorf("gagal menghasilkan konten dari AI: %w", err)
+		return nil, fmt.Errorf("gagal menghasilkan konten dari AI: %w", err)
+	}
+
+	// Pemakaian dicatat sebelum teks diperiksa: respons yang diblokir atau terpotong tetap memakai token
+	usage := Usage{Provider: "gemini", Model: s.Config.Model, Latency: time.Since(start)}
+	if result.ModelVersion != "" {
+		usage.Model = result.ModelVersion
+	}
+	if meta := result.UsageMetadata; meta != nil {
+		usage.PromptTokens = int(meta.PromptTokenCount)
+		usage.CompletionTokens = int(meta.CandidatesTokenCount + meta.ThoughtsTokenCount)
+		usage.TotalTokens = int(meta.TotalTokenCount)
 	}
 
 	text := result.Text()
 	if text == "" {
-		return "", fmt.Errorf("tidak ada konten teks yang ditemukan dalam respons AI")
+		return nil, withUsage(fmt.Errorf("tidak ada konten teks yang ditemukan dalam respons AI"), usage)
 	}
-
-	return text, nil
+	return &Completion{Text: text, Usage: usage}, nil
 }
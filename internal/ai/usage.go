@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"errors"
+	"time"
+)
+
+// Usage adalah pemakaian token dan latensi satu panggilan ke provider
+type Usage struct {
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Latency          time.Duration
+}
+
+// Completion adalah hasil satu panggilan ke provider. Failed berisi pemakaian percobaan gagal
+// sebelumnya (retry) yang tetap memakai token.
+type Completion struct {
+	Text   string
+	Usage  Usage
+	Failed []Usage
+}
+
+// UsageError adalah kegagalan yang tetap memakai token, mis. respons yang diblokir safety filter,
+// terpotong MAX_TOKENS, atau percobaan retry yang gagal. Pemakaiannya tetap dicatat ke budget.
+type UsageError struct {
+	Usages []Usage
+	Err    error
+}
+
+func (e *UsageError) Error() string { return e.Err.Error() }
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// withUsage membungkus err dengan pemakaian token, atau mengembalikan err apa adanya jika tidak ada
+func withUsage(err error, usages ...Usage) error {
+	if err == nil || len(usages) == 0 {
+		return err
+	}
+	return &UsageError{Usages: usages, Err: err}
+}
+
+// UsagesOf mengembalikan pemakaian token yang dibawa err
+func UsagesOf(err error) []Usage {
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		return usageErr.Usages
+	}
+	return nil
+}
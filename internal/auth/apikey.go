@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const apiKeyPrefix = "dsk"
+
+// GenerateAPIKey menghasilkan API key baru dengan format dsk_<prefix>_<secret>.
+// Key utuh hanya ditampilkan sekali; yang disimpan adalah prefix dan hash-nya.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	idBytes := make([]byte, 4)
+	secretBytes := make([]byte, 32)
+	if _, err = rand.Read(idBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	prefix = apiKeyPrefix + "_" + hex.EncodeToString(idBytes)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return key, prefix, HashToken(key), nil
+}
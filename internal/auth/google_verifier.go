@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/idtoken"
+)
+
+// GoogleVerifier memvalidasi Google ID Token ke server Google
+type GoogleVerifier struct{}
+
+func NewGoogleVerifier() *GoogleVerifier {
+	return &GoogleVerifier{}
+}
+
+func (v *GoogleVerifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	payload, err := idtoken.Validate(ctx, token, "")
+	if err != nil {
+		// idtoken tidak punya error bertipe untuk token kedaluwarsa
+		if strings.Contains(err.Error(), "token expired") {
+			return nil, fmt.Errorf("%w: %v", ErrTokenExpired, err)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	id := identityFromClaims(payload.Claims)
+	id.Subject = payload.Subject
+	id.Issuer = payload.Issuer
+	id.Audience = payload.Audience
+	return id, nil
+}
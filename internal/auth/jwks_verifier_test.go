@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testJWKSIssuer = "https://idp.example.com"
+
+// newJWKSServer menyajikan public key RSA dengan kid "key-1" seperti endpoint JWKS penyedia OIDC
+func newJWKSServer(t *testing.T, key *rsa.PrivateKey) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	server := newJWKSServer(t, key)
+	verifier, err := NewJWKSVerifier(server.URL, testJWKSIssuer, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWKSVerifier: %v", err)
+	}
+
+	sign := func(signer *rsa.PrivateKey, kid, issuer string, ttl time.Duration) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            issuer,
+			"sub":            "user-1",
+			"aud":            []string{"client-1"},
+			"email":          "user@example.com",
+			"email_verified": "true",
+			"exp":            time.Now().Add(ttl).Unix(),
+		})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(signer)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", sign(key, "key-1", testJWKSIssuer, time.Minute), nil},
+		{"expired", sign(key, "key-1", testJWKSIssuer, -time.Minute), ErrTokenExpired},
+		{"other issuer", sign(key, "key-1", "https://evil.example.com", time.Minute), ErrInvalidToken},
+		{"unknown kid", sign(key, "key-2", testJWKSIssuer, time.Minute), ErrInvalidToken},
+		{"signed with another key", sign(otherKey, "key-1", testJWKSIssuer, time.Minute), ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(context.Background(), tt.token)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Verify error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got.Subject != "user-1" || got.Audience != "client-1" || !got.EmailVerified || got.Issuer != testJWKSIssuer {
+				t.Errorf("Verify = %+v", got)
+			}
+		})
+	}
+}
+
+func TestJWKSVerifierRejectsHMAC(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	verifier, err := NewJWKSVerifier(newJWKSServer(t, key).URL, "", time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWKSVerifier: %v", err)
+	}
+	// Token HS256 yang ditandatangani dengan public key tidak boleh diterima (algorithm confusion)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Minute).Unix()})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key.N.Bytes())
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), signed); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify error = %v, want %v", err, ErrInvalidToken)
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrAudienceNotAllowed     = errors.New("token audience is not an allowed client id")
+	ErrIssuerNotAllowed       = errors.New("token issuer is not allowed")
+	ErrEmailNotVerified       = errors.New("email address is not verified")
+	ErrHostedDomainNotAllowed = errors.New("hosted domain is not allowed")
+)
+
+var googleIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+// Policy adalah aturan tambahan yang harus dipenuhi ID token setelah tanda tangannya valid
+type Policy struct {
+	AllowedClientIDs     []string
+	AllowedIssuers       []string
+	RequireEmailVerified bool
+	AllowedHostedDomains []string
+}
+
+// PolicyFromConfig membaca auth.allowed_client_ids, auth.allowed_issuers,
+// auth.require_email_verified dan auth.allowed_hosted_domains
+func PolicyFromConfig() Policy {
+	p := Policy{
+		AllowedClientIDs:     configList("auth.allowed_client_ids"),
+		AllowedIssuers:       configList("auth.allowed_issuers"),
+		RequireEmailVerified: true,
+		AllowedHostedDomains: configList("auth.allowed_hosted_domains"),
+	}
+	if viper.IsSet("auth.require_email_verified") {
+		p.RequireEmailVerified = viper.GetBool("auth.require_email_verified")
+	}
+	return p
+}
+
+func (p Policy) Check(id *Identity) error {
+	if len(p.AllowedClientIDs) > 0 && !containsFold(p.AllowedClientIDs, id.Audience) {
+		return fmt.Errorf("%w: %q", ErrAudienceNotAllowed, id.Audience)
+	}
+	if len(p.AllowedIssuers) > 0 && !containsFold(p.AllowedIssuers, id.Issuer) {
+		return fmt.Errorf("%w: %q", ErrIssuerNotAllowed, id.Issuer)
+	}
+	if p.RequireEmailVerified && !id.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	if len(p.AllowedHostedDomains) > 0 && !containsFold(p.AllowedHostedDomains, id.HostedDomain) {
+		return fmt.Errorf("%w: %q", ErrHostedDomainNotAllowed, id.HostedDomain)
+	}
+	return nil
+}
+
+// policyVerifier membungkus TokenVerifier dan menerapkan Policy pada hasilnya
+type policyVerifier struct {
+	TokenVerifier
+	policy Policy
+}
+
+func WithPolicy(v TokenVerifier, p Policy) TokenVerifier {
+	return &policyVerifier{TokenVerifier: v, policy: p}
+}
+
+func (v *policyVerifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	id, err := v.TokenVerifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.policy.Check(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// Rejection memetakan error verifikasi ke kode error yang stabil untuk klien
+func Rejection(err error) (code, message string) {
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		return "token_expired", "Token has expired"
+	case errors.Is(err, ErrAudienceNotAllowed):
+		return "invalid_audience", "Token was issued for a different application"
+	case errors.Is(err, ErrIssuerNotAllowed):
+		return "invalid_issuer", "Token issuer is not allowed"
+	case errors.Is(err, ErrEmailNotVerified):
+		return "email_not_verified", "Email address is not verified"
+	case errors.Is(err, ErrHostedDomainNotAllowed):
+		return "hosted_domain_not_allowed", "Account domain is not allowed for this deployment"
+	default:
+		return "invalid_token", "Invalid Google ID Token"
+	}
+}
+
+// configList membaca daftar dari config.yaml (array) atau env var (dipisah koma)
+func configList(key string) []string {
+	var out []string
+	for _, item := range viper.GetStringSlice(key) {
+		for _, part := range strings.Split(item, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdminEmail menandai email di auth.admin_emails sebagai admin awal,
+// agar deployment baru punya minimal satu admin tanpa SQL manual
+func IsAdminEmail(email string) bool {
+	return email != "" && containsFold(configList("auth.admin_emails"), email)
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		AllowedClientIDs:     []string{"android-client", "web-client"},
+		AllowedIssuers:       []string{"https://accounts.google.com"},
+		RequireEmailVerified: true,
+		AllowedHostedDomains: []string{"sekolah.sch.id"},
+	}
+	valid := Identity{
+		Audience:      "web-client",
+		Issuer:        "https://accounts.google.com",
+		EmailVerified: true,
+		HostedDomain:  "sekolah.sch.id",
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		modify func(*Identity)
+		want   error
+	}{
+		{"valid", policy, func(*Identity) {}, nil},
+		{"client id is case-insensitive", policy, func(id *Identity) { id.Audience = "WEB-CLIENT" }, nil},
+		{"other client id", policy, func(id *Identity) { id.Audience = "other-app" }, ErrAudienceNotAllowed},
+		{"missing audience", policy, func(id *Identity) { id.Audience = "" }, ErrAudienceNotAllowed},
+		{"other issuer", policy, func(id *Identity) { id.Issuer = "https://evil.example.com" }, ErrIssuerNotAllowed},
+		{"unverified email", policy, func(id *Identity) { id.EmailVerified = false }, ErrEmailNotVerified},
+		{"other hosted domain", policy, func(id *Identity) { id.HostedDomain = "gmail.com" }, ErrHostedDomainNotAllowed},
+		{"consumer account", policy, func(id *Identity) { id.HostedDomain = "" }, ErrHostedDomainNotAllowed},
+		{"empty policy allows all", Policy{}, func(id *Identity) { *id = Identity{} }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := valid
+			tt.modify(&id)
+			if err := tt.policy.Check(&id); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("Check = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPolicyRejections(t *testing.T) {
+	static, err := NewStaticKeyVerifier("test-secret", "")
+	if err != nil {
+		t.Fatalf("NewStaticKeyVerifier: %v", err)
+	}
+	verifier := WithPolicy(static, Policy{
+		AllowedClientIDs:     []string{"client-1"},
+		RequireEmailVerified: true,
+		AllowedHostedDomains: []string{"sekolah.sch.id"},
+	})
+	valid := Identity{Subject: "user-1", Audience: "client-1", EmailVerified: true, HostedDomain: "sekolah.sch.id"}
+
+	tests := []struct {
+		name   string
+		modify func(*Identity)
+		ttl    time.Duration
+		code   string
+	}{
+		{"valid", func(*Identity) {}, time.Minute, ""},
+		{"expired", func(*Identity) {}, -time.Minute, "token_expired"},
+		{"other audience", func(id *Identity) { id.Audience = "client-2" }, time.Minute, "invalid_audience"},
+		{"unverified email", func(id *Identity) { id.EmailVerified = false }, time.Minute, "email_not_verified"},
+		{"other hosted domain", func(id *Identity) { id.HostedDomain = "gmail.com" }, time.Minute, "hosted_domain_not_allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := valid
+			tt.modify(&id)
+			token, err := static.Mint(id, tt.ttl)
+			if err != nil {
+				t.Fatalf("Mint: %v", err)
+			}
+			_, err = verifier.Verify(context.Background(), token)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if code, _ := Rejection(err); code != tt.code {
+				t.Fatalf("Rejection code = %q (error %v), want %q", code, err, tt.code)
+			}
+		})
+	}
+}
+
+func TestNewVerifierFromConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		wantFail bool
+	}{
+		{"google without client ids", map[string]interface{}{"auth.verifier": "google"}, true},
+		{"google with client ids", map[string]interface{}{"auth.verifier": "google", "auth.allowed_client_ids": []string{"client-1"}}, false},
+		{"google client ids from env string", map[string]interface{}{"auth.allowed_client_ids": "client-1, client-2"}, false},
+		{"static without secret", map[string]interface{}{"auth.verifier": "static"}, true},
+		{"static", map[string]interface{}{"auth.verifier": "static", "auth.static.secret_key": "secret"}, false},
+		{"jwks without url", map[string]interface{}{"auth.verifier": "jwks"}, true},
+		{"unknown", map[string]interface{}{"auth.verifier": "saml"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			for key, value := range tt.config {
+				viper.Set(key, value)
+			}
+			_, err := NewVerifierFromConfig()
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("NewVerifierFromConfig error = %v, want failure %v", err, tt.wantFail)
+			}
+		})
+	}
+}
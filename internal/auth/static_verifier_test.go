@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStaticKeyVerifier(t *testing.T) {
+	verifier, err := NewStaticKeyVerifier("test-secret", "")
+	if err != nil {
+		t.Fatalf("NewStaticKeyVerifier: %v", err)
+	}
+	otherKey, _ := NewStaticKeyVerifier("other-secret", "")
+	otherIssuer, _ := NewStaticKeyVerifier("test-secret", "other-issuer")
+
+	identity := Identity{
+		Subject:       "user-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Name:          "User",
+		HostedDomain:  "sekolah.sch.id",
+		Audience:      "client-1",
+	}
+	mint := func(v *StaticKeyVerifier, ttl time.Duration) string {
+		token, err := v.Mint(identity, ttl)
+		if err != nil {
+			t.Fatalf("Mint: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", mint(verifier, time.Minute), nil},
+		{"expired", mint(verifier, -time.Minute), ErrTokenExpired},
+		{"signed with another key", mint(otherKey, time.Minute), ErrInvalidToken},
+		{"another issuer", mint(otherIssuer, time.Minute), ErrInvalidToken},
+		{"not a JWT", "not-a-token", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(context.Background(), tt.token)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Verify error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got.Subject != identity.Subject || got.Email != identity.Email || !got.EmailVerified ||
+				got.HostedDomain != identity.HostedDomain || got.Audience != identity.Audience || got.Issuer != defaultStaticIssuer {
+				t.Errorf("Verify = %+v, want claims of %+v", got, identity)
+			}
+		})
+	}
+}
+
+func TestNewStaticKeyVerifierRequiresSecret(t *testing.T) {
+	if _, err := NewStaticKeyVerifier("", ""); err == nil {
+		t.Fatal("NewStaticKeyVerifier accepted an empty secret")
+	}
+}
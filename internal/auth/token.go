@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"Dysec/internal/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	tokenIssuer            = "dysec"
+)
+
+// Claims adalah isi access token yang kita tandatangani sendiri
+type Claims struct {
+	UserID    uint   `json:"user_id"`
+	SessionID uint   `json:"sid"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager menerbitkan dan memverifikasi access token aplikasi (HS256)
+type TokenManager struct {
+	secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+func NewTokenManager(secret string, accessTTL, refreshTTL time.Duration) (*TokenManager, error) {
+	if secret == "" {
+		return nil, errors.New("jwt secret key is empty")
+	}
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+	return &TokenManager{secret: []byte(secret), AccessTokenTTL: accessTTL, RefreshTokenTTL: refreshTTL}, nil
+}
+
+// NewTokenManagerFromConfig membaca JWT_SECRET_KEY (env) atau jwt.* (config.yaml)
+func NewTokenManagerFromConfig() (*TokenManager, error) {
+	secret := viper.GetString("JWT_SECRET_KEY")
+	if secret == "" {
+		secret = viper.GetString("jwt.secret_key")
+	}
+	return NewTokenManager(
+		secret,
+		viper.GetDuration("jwt.access_token_ttl"),
+		viper.GetDuration("jwt.refresh_token_ttl"),
+	)
+}
+
+func (m *TokenManager) GenerateAccessToken(user models.User, sessionID uint) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.AccessTokenTTL)
+	claims := Claims{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		Email:     user.Email,
+		Role:      user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   fmt.Sprint(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return tokenString, expiresAt, nil
+}
+
+func (m *TokenManager) ParseAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// GenerateRefreshToken menghasilkan refresh token acak (opaque) beserta hash-nya.
+// Hanya hash yang disimpan di database.
+func GenerateRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+	return raw, HashToken(raw), nil
+}
+
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid identity token")
+	ErrTokenExpired = errors.New("identity token has expired")
+)
+
+// Identity adalah data user yang diambil dari ID token penyedia identitas
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+	HostedDomain  string
+	Issuer        string
+	Audience      string
+	Claims        map[string]interface{}
+}
+
+// TokenVerifier memverifikasi ID token dari penyedia identitas (Google, OIDC lain, atau kunci lokal)
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Identity, error)
+}
+
+// NewVerifierFromConfig memilih verifier berdasarkan auth.verifier: google (default), jwks, atau static,
+// lalu membungkusnya dengan Policy dari config
+func NewVerifierFromConfig() (TokenVerifier, error) {
+	policy := PolicyFromConfig()
+
+	var base TokenVerifier
+	var err error
+	kind := strings.ToLower(viper.GetString("auth.verifier"))
+	switch kind {
+	case "", "google":
+		// Tanpa daftar client ID, token Google milik aplikasi mana pun akan diterima
+		if len(policy.AllowedClientIDs) == 0 {
+			return nil, errors.New("auth.allowed_client_ids must list at least one OAuth client ID for the google verifier")
+		}
+		if len(policy.AllowedIssuers) == 0 {
+			policy.AllowedIssuers = googleIssuers
+		}
+		base = NewGoogleVerifier()
+	case "jwks", "oidc":
+		base, err = NewJWKSVerifier(
+			viper.GetString("auth.jwks.url"),
+			viper.GetString("auth.jwks.issuer"),
+			viper.GetDuration("auth.jwks.cache_ttl"),
+		)
+	case "static":
+		base, err = NewStaticKeyVerifier(
+			viper.GetString("auth.static.secret_key"),
+			viper.GetString("auth.static.issuer"),
+		)
+	default:
+		return nil, fmt.Errorf("unknown auth.verifier %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return WithPolicy(base, policy), nil
+}
+
+// identityFromClaims mengisi Identity dari klaim standar OIDC
+func identityFromClaims(claims map[string]interface{}) *Identity {
+	id := &Identity{
+		Subject:       claimString(claims, "sub"),
+		Email:         claimString(claims, "email"),
+		EmailVerified: claimBool(claims, "email_verified"),
+		Name:          claimString(claims, "name"),
+		Picture:       claimString(claims, "picture"),
+		HostedDomain:  claimString(claims, "hd"),
+		Issuer:        claimString(claims, "iss"),
+		Claims:        claims,
+	}
+	switch aud := claims["aud"].(type) {
+	case string:
+		id.Audience = aud
+	case []interface{}:
+		if len(aud) > 0 {
+			id.Audience = fmt.Sprint(aud[0])
+		}
+	}
+	return id
+}
+
+func claimString(claims map[string]interface{}, key string) string {
+	if v, ok := claims[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func claimBool(claims map[string]interface{}, key string) bool {
+	switch v := claims[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package cat
+
+import (
+	"Dysec/internal/models"
+	"math"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// Item adalah parameter IRT 2PL satu soal: A (discrimination) dan B (difficulty) pada skala ability
+type Item struct {
+	ID string
+	A  float64
+	B  float64
+}
+
+// Response adalah jawaban benar/salah untuk satu item
+type Response struct {
+	Item    Item
+	Correct bool
+}
+
+// Probability adalah peluang menjawab benar pada ability theta (model logistik 2 parameter)
+func Probability(theta float64, item Item) float64 {
+	return 1 / (1 + math.Exp(-item.A*(theta-item.B)))
+}
+
+// Information adalah Fisher information item pada theta
+func Information(theta float64, item Item) float64 {
+	p := Probability(theta, item)
+	return item.A * item.A * p * (1 - p)
+}
+
+const (
+	quadraturePoints = 81
+	quadratureRange  = 4.0
+)
+
+// Estimate menghitung ability dengan EAP (expected a posteriori) dan prior normal(priorMean, 1).
+// EAP tetap terdefinisi saat semua jawaban benar atau semua salah, tidak seperti maximum likelihood.
+// se adalah simpangan baku posterior.
+func Estimate(responses []Response, priorMean float64) (theta, se float64) {
+	var sumW, sumWT, sumWT2 float64
+	for i := 0; i < quadraturePoints; i++ {
+		t := priorMean - quadratureRange + 2*quadratureRange*float64(i)/float64(quadraturePoints-1)
+
+		// Likelihood dihitung dalam log agar tidak underflow untuk tes panjang
+		logW := -(t - priorMean) * (t - priorMean) / 2
+		for _, r := range responses {
+			p := Probability(t, r.Item)
+			if r.Correct {
+				logW += math.Log(p)
+			} else {
+				logW += math.Log(1 - p)
+			}
+		}
+		w := math.Exp(logW)
+
+		sumW += w
+		sumWT += w * t
+		sumWT2 += w * t * t
+	}
+
+	theta = sumWT / sumW
+	se = math.Sqrt(math.Max(0, sumWT2/sumW-theta*theta))
+	return theta, se
+}
+
+// SelectNext memilih item dengan information terbesar pada theta dari kandidat yang belum diberikan.
+// Hasil seri diputuskan dengan ID agar pemilihan deterministik. ok bernilai false jika kandidat habis.
+func SelectNext(theta float64, candidates []Item, administered map[string]bool) (best Item, ok bool) {
+	bestInfo := -1.0
+	for _, item := range candidates {
+		if administered[item.ID] {
+			continue
+		}
+		info := Information(theta, item)
+		if info > bestInfo || (info == bestInfo && item.ID < best.ID) {
+			best, bestInfo, ok = item, info, true
+		}
+	}
+	return best, ok
+}
+
+// BalanceOrder mengurutkan subtes dari yang paling sedikit diberikan, seri mengikuti urutan subtests.
+// Soal berikutnya diambil dari subtes pertama yang masih punya kandidat (content balancing), sehingga
+// tes adaptif mencakup semua subtes walaupun item paling informatif menumpuk di satu subtes.
+func BalanceOrder(subtests []string, administered map[string]int) []string {
+	order := append([]string(nil), subtests...)
+	sort.SliceStable(order, func(i, j int) bool { return administered[order[i]] < administered[order[j]] })
+	return order
+}
+
+// StopRule menghentikan tes saat presisi tercapai (SE <= SETarget setelah MinItems) atau panjang mencapai MaxItems
+type StopRule struct {
+	MinItems int     `json:"min_items"`
+	MaxItems int     `json:"max_items"`
+	SETarget float64 `json:"se_target"`
+}
+
+// StopRuleFromConfig membaca cat.min_items, cat.max_items, dan cat.se_target
+func StopRuleFromConfig() StopRule {
+	rule := StopRule{
+		MinItems: viper.GetInt("cat.min_items"),
+		MaxItems: viper.GetInt("cat.max_items"),
+		SETarget: viper.GetFloat64("cat.se_target"),
+	}
+	if rule.MaxItems <= 0 {
+		rule.MaxItems = 20
+	}
+	if rule.MinItems <= 0 || rule.MinItems > rule.MaxItems {
+		rule.MinItems = min(5, rule.MaxItems)
+	}
+	if rule.SETarget <= 0 {
+		rule.SETarget = 0.35
+	}
+	return rule
+}
+
+// Done memeriksa apakah tes harus berhenti setelah administered item dengan standard error se
+func (r StopRule) Done(administered int, se float64) bool {
+	if administered >= r.MaxItems {
+		return true
+	}
+	return administered >= r.MinItems && se <= r.SETarget
+}
+
+// DefaultDifficulty memetakan label kesulitan bank soal ke parameter B awal
+// sebelum item dikalibrasi dari data respons
+func DefaultDifficulty(difficulty string) float64 {
+	switch difficulty {
+	case models.DifficultyEasy:
+		return -1
+	case models.DifficultyHard:
+		return 1
+	default:
+		return 0
+	}
+}
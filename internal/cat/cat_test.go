@@ -0,0 +1,133 @@
+package cat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func responses(correct ...bool) []Response {
+	out := make([]Response, len(correct))
+	for i, c := range correct {
+		out[i] = Response{Item: Item{ID: string(rune('a' + i)), A: 1, B: 0}, Correct: c}
+	}
+	return out
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []Response
+		prior     float64
+		check     func(theta, se float64) bool
+		want      string
+	}{
+		{"no responses returns the prior", nil, 0.5, func(theta, se float64) bool {
+			return math.Abs(theta-0.5) < 1e-6 && math.Abs(se-1) < 0.01
+		}, "theta = prior, se = 1"},
+		{"all correct is finite and above the prior", responses(true, true, true, true), 0, func(theta, se float64) bool {
+			return theta > 0.5 && !math.IsInf(theta, 0)
+		}, "theta > 0.5"},
+		{"all wrong is finite and below the prior", responses(false, false, false, false), 0, func(theta, se float64) bool {
+			return theta < -0.5 && !math.IsInf(theta, 0)
+		}, "theta < -0.5"},
+		{"balanced answers stay at the prior", responses(true, false, true, false), 0, func(theta, se float64) bool {
+			return math.Abs(theta) < 1e-6
+		}, "theta = 0"},
+		{"responses reduce se", responses(true, false, true, false, true, false), 0, func(theta, se float64) bool {
+			return se < 0.8
+		}, "se < 0.8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theta, se := Estimate(tt.responses, tt.prior)
+			if !tt.check(theta, se) {
+				t.Fatalf("theta = %.3f, se = %.3f, want %s", theta, se, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateLongTestDoesNotUnderflow(t *testing.T) {
+	correct := make([]bool, 500)
+	for i := range correct {
+		correct[i] = i%3 != 0
+	}
+	theta, se := Estimate(responses(correct...), 0)
+	if math.IsNaN(theta) || math.IsNaN(se) {
+		t.Fatalf("theta = %v, se = %v", theta, se)
+	}
+}
+
+func TestSelectNext(t *testing.T) {
+	easy := Item{ID: "easy", A: 1, B: -2}
+	medium := Item{ID: "medium", A: 1, B: 0}
+	hard := Item{ID: "hard", A: 1, B: 2}
+	sharp := Item{ID: "sharp", A: 2, B: 0.3}
+	tests := []struct {
+		name         string
+		theta        float64
+		candidates   []Item
+		administered map[string]bool
+		want         string
+		wantOK       bool
+	}{
+		{"closest difficulty", 0, []Item{easy, medium, hard}, nil, "medium", true},
+		{"follows theta", 1.8, []Item{easy, medium, hard}, nil, "hard", true},
+		{"higher discrimination wins", 0, []Item{easy, medium, hard, sharp}, nil, "sharp", true},
+		{"skips administered items", 0, []Item{easy, medium, hard}, map[string]bool{"medium": true}, "easy", true},
+		{"ties broken by id", 0, []Item{{ID: "b", A: 1}, {ID: "a", A: 1}}, nil, "a", true},
+		{"no candidates left", 0, []Item{medium}, map[string]bool{"medium": true}, "", false},
+		{"empty bank", 0, nil, nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SelectNext(tt.theta, tt.candidates, tt.administered)
+			if ok != tt.wantOK || got.ID != tt.want {
+				t.Fatalf("got (%q, %v), want (%q, %v)", got.ID, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBalanceOrder(t *testing.T) {
+	subtests := []string{"dot", "stroop", "addition"}
+	tests := []struct {
+		name         string
+		administered map[string]int
+		want         []string
+	}{
+		{"nothing administered keeps the order", nil, []string{"dot", "stroop", "addition"}},
+		{"least administered first", map[string]int{"dot": 2, "stroop": 1}, []string{"addition", "stroop", "dot"}},
+		{"ties keep the order", map[string]int{"dot": 1, "stroop": 1, "addition": 1}, []string{"dot", "stroop", "addition"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BalanceOrder(subtests, tt.administered); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopRuleDone(t *testing.T) {
+	rule := StopRule{MinItems: 5, MaxItems: 10, SETarget: 0.4}
+	tests := []struct {
+		name         string
+		administered int
+		se           float64
+		want         bool
+	}{
+		{"precise but too short", 3, 0.2, false},
+		{"precise after min items", 5, 0.4, true},
+		{"imprecise", 7, 0.6, false},
+		{"max items", 10, 0.9, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.Done(tt.administered, tt.se); got != tt.want {
+				t.Fatalf("Done(%d, %v) = %v, want %v", tt.administered, tt.se, got, tt.want)
+			}
+		})
+	}
+}
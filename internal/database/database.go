@@ -38,18 +38,39 @@ func Connect() (*gorm.DB, error) {
 		host, user, password, dbname, port,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError agar pelanggaran index unik bisa dikenali dengan gorm.ErrDuplicatedKey
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	log.Println("Database connection established")
 
-	err = db.AutoMigrate(&models.User{}, &models.UserTest{}, &models.AiScore{}, &models.Question{})
+	if err := dropQuestionIDConstraint(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate questions.question_id index: %w", err)
+	}
+
+	err = db.AutoMigrate(
+		&models.User{}, &models.UserTest{}, &models.AiScore{}, &models.Question{},
+		&models.Session{}, &models.RefreshToken{}, &models.ChildProfile{},
+		&models.AuditLog{}, &models.DeletionRequest{},
+		&models.Organization{}, &models.APIKey{}, &models.RateLimitBucket{},
+		&models.PromptTemplate{}, &models.AIUsage{}, &models.ItemResponse{},
+		&models.QuestionVersion{},
+		&models.ItemStatistic{},
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if err := backfillContentHashes(db); err != nil {
+		return nil, fmt.Errorf("failed to backfill question content hashes: %w", err)
+	}
+
+	if err := backfillScoreUpdatedAt(db); err != nil {
+		return nil, fmt.Errorf("failed to backfill ai_scores.updated_at: %w", err)
+	}
+
 	log.Println("Database migrated successfully")
 
 	return db, nil
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"Dysec/internal/models"
+	"Dysec/internal/testset"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// dropQuestionIDConstraint menghapus constraint unik lama pada questions.question_id (dari tag `unique`)
+// sebelum AutoMigrate membuat index idx_questions_question_id. Nama constraint lama berbeda menurut
+// versi GORM yang membuat tabel, jadi dicari dari katalog Postgres.
+func dropQuestionIDConstraint(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Question{}) {
+		return nil
+	}
+	return db.Exec(`
+DO $$
+DECLARE r record;
+BEGIN
+	FOR r IN
+		SELECT con.conname
+		FROM pg_constraint con
+		JOIN pg_class rel ON rel.oid = con.conrelid
+		JOIN pg_attribute att ON att.attrelid = rel.oid AND att.attnum = con.conkey[1]
+		WHERE rel.relname = 'questions' AND rel.relnamespace = current_schema()::regnamespace
+			AND con.contype = 'u' AND array_length(con.conkey, 1) = 1 AND att.attname = 'question_id'
+	LOOP
+		EXECUTE format('ALTER TABLE questions DROP CONSTRAINT %I', r.conname);
+	END LOOP;
+END $$`).Error
+}
+
+// backfillContentHashes mengisi content_hash soal lama. Soal aktif yang isinya sama dengan soal aktif
+// lain yang lebih dulu dibuat di-retire, karena index unik idx_questions_content_hash hanya
+// mengizinkan satu soal aktif per isi. Soal yang di-retire tetap ada untuk tes lama yang memakainya.
+//
+// ID lama dari AI (mis. "add_1") sengaja tidak diganti ke format "q_...": ID tersebut tersimpan di
+// answer_key, correction_results, dan item_responses tes yang sudah ada, sehingga mengganti ID akan
+// memutus riwayat itu. Soal baru selalu mendapat ID server dari questionbank.NewQuestionID.
+func backfillContentHashes(db *gorm.DB) error {
+	var pending []models.Question
+	if err := db.Where("content_hash = ''").Order("id asc").Find(&pending).Error; err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var hashes []string
+	err := db.Model(&models.Question{}).
+		Where("content_hash <> '' AND status = ?", models.QuestionStatusActive).
+		Pluck("content_hash", &hashes).Error
+	if err != nil {
+		return err
+	}
+	active := map[string]bool{}
+	for _, h := range hashes {
+		active[h] = true
+	}
+
+	retired := 0
+	for _, q := range pending {
+		hash, err := testset.ContentHash(q.SubtestName, q.QuestionData)
+		if err != nil {
+			log.Printf("WARNING: Could not hash question %s: %v", q.QuestionID, err)
+			continue
+		}
+		updates := map[string]interface{}{"content_hash": hash}
+		if q.Status == models.QuestionStatusActive {
+			if active[hash] {
+				updates["status"] = models.QuestionStatusRetired
+				retired++
+			}
+			active[hash] = true
+		}
+		if err := db.Model(&models.Question{}).Where("id = ?", q.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Question content hashes backfilled: %d questions, %d duplicates retired", len(pending), retired)
+	return nil
+}
+
+// backfillScoreUpdatedAt mengisi ai_scores.updated_at skor lama dengan created_at agar skor itu tetap
+// terkirim lewat cursor API integrasi. user_tests.organization_id tes lama sengaja tidak diisi dari
+// organisasi user saat ini: tidak diketahui apakah tes itu dikerjakan setelah user bergabung.
+func backfillScoreUpdatedAt(db *gorm.DB) error {
+	return db.Model(&models.AiScore{}).Where("updated_at IS NULL").Update("updated_at", gorm.Expr("created_at")).Error
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"Dysec/internal/auth"
+	"Dysec/internal/models"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const deletionConfirmationTTL = 15 * time.Minute
+
+type exportedTest struct {
+	TestID            uint                  `json:"test_id"`
+	ProfileID         *uint                 `json:"profile_id"`
+	AnswerKey         json.RawMessage       `json:"answer_key"`
+	CorrectionResults json.RawMessage       `json:"correction_results"`
+	AiScore           *models.AiScore       `json:"ai_score"`
+	Mode              string                `json:"mode"`
+	Ability           *float64              `json:"ability,omitempty"`
+	Responses         []models.ItemResponse `json:"responses"`
+	CreatedAt         time.Time             `json:"created_at"`
+}
+
+// ExportDataHandler mengembalikan seluruh data pribadi user (UU PDP) sebagai JSON atau ZIP (?format=zip)
+func (h *Handler) ExportDataHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var profiles []models.ChildProfile
+	var tests []models.UserTest
+	var sessions []models.Session
+	var responses []models.ItemResponse
+	var usages []models.AIUsage
+	err := h.DB.Where("guardian_id = ?", userID).Find(&profiles).Error
+	if err == nil {
+		err = h.DB.Preload("AiScore").Where("user_id = ?", userID).Order("created_at asc").Find(&tests).Error
+	}
+	if err == nil {
+		testIDs := h.DB.Model(&models.UserTest{}).Select("test_id").Where("user_id = ?", userID)
+		err = h.DB.Where("test_id IN (?)", testIDs).Order("test_id asc, position asc").Find(&responses).Error
+	}
+	if err == nil {
+		err = h.DB.Where("user_id = ?", userID).Find(&sessions).Error
+	}
+	if err == nil {
+		err = h.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&usages).Error
+	}
+	if err != nil {
+		log.Printf("ERROR: Could not collect export data for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+
+	responsesByTest := map[uint][]models.ItemResponse{}
+	for _, r := range responses {
+		responsesByTest[r.TestID] = append(responsesByTest[r.TestID], r)
+	}
+
+	exported := make([]exportedTest, 0, len(tests))
+	for _, t := range tests {
+		et := exportedTest{
+			TestID:            t.TestID,
+			ProfileID:         t.ProfileID,
+			AnswerKey:         t.AnswerKey,
+			CorrectionResults: t.CorrectionResults,
+			Mode:              t.Mode,
+			Ability:           t.Ability,
+			Responses:         responsesByTest[t.TestID],
+			CreatedAt:         t.CreatedAt,
+		}
+		if t.AiScore.ID != 0 {
+			score := t.AiScore
+			et.AiScore = &score
+		}
+		exported = append(exported, et)
+	}
+
+	archive := map[string]interface{}{
+		"user":     user,
+		"profiles": profiles,
+		"tests":    exported,
+		"sessions": sessions,
+		"ai_usage": usages,
+	}
+	audit(h.DB, c, userID, "user.data_exported", "user", userID, nil)
+
+	filename := fmt.Sprintf("dysec-export-%d-%s", userID, time.Now().Format("20060102"))
+	if c.Query("format") != "zip" {
+		archive["exported_at"] = time.Now()
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		c.JSON(http.StatusOK, archive)
+		return
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range archive {
+		w, err := zw.Create(name + ".json")
+		if err == nil {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(data)
+		}
+		if err != nil {
+			log.Printf("ERROR: Could not write %s to export archive: %v", name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// DeleteAccountHandler menghapus akun dalam dua langkah: tanpa confirmation_token akan
+// mengembalikan token konfirmasi, dengan token yang valid akan menghapus seluruh data user.
+func (h *Handler) DeleteAccountHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body structure"})
+		return
+	}
+
+	if req.ConfirmationToken == "" {
+		token, tokenHash, err := auth.GenerateRefreshToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start account deletion"})
+			return
+		}
+		deletion := models.DeletionRequest{
+			UserID:    userID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(deletionConfirmationTTL),
+		}
+		if err := h.DB.Create(&deletion).Error; err != nil {
+			log.Printf("ERROR: Could not create deletion request for user %d: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start account deletion"})
+			return
+		}
+		audit(h.DB, c, userID, "user.deletion_requested", "user", userID, nil)
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":            "Send DELETE /users/me again with this confirmation_token to permanently delete your account and all related data",
+			"confirmation_token": token,
+			"expires_at":         deletion.ExpiresAt,
+		})
+		return
+	}
+
+	var deletion models.DeletionRequest
+	err := h.DB.Where("token_hash = ? AND user_id = ? AND expires_at > ?",
+		auth.HashToken(req.ConfirmationToken), userID, time.Now()).First(&deletion).Error
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation_token"})
+		return
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Jejak audit penghapusan hanya menyimpan ID dan HMAC email. Alamat IP di baris ini dan
+	// di semua jejak audit user sebelumnya dikosongkan oleh deleteUserData.
+	var details gin.H
+	if digest := emailDigest(h.AuditEmailKey, user.Email); digest != "" {
+		details = gin.H{"email_hmac": digest}
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := auditTx(tx, c, userID, "user.deleted", "user", userID, details); err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+		return deleteUserData(tx, userID)
+	})
+	if err != nil {
+		log.Printf("ERROR: Could not delete account %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account and all related data deleted successfully"})
+}
+
+// deleteUserData menghapus user beserta semua baris yang terkait dengannya. Baris ai_usages dan
+// audit_logs tidak dihapus, tetapi ai_usages dilepas dari user dan tesnya dan alamat IP di
+// audit_logs dikosongkan.
+func deleteUserData(tx *gorm.DB, userID uint) error {
+	testIDs := tx.Model(&models.UserTest{}).Select("test_id").Where("user_id = ?", userID)
+	err := tx.Model(&models.AIUsage{}).
+		Where("user_id = ? OR test_id IN (?)", userID, testIDs).
+		Updates(map[string]interface{}{"user_id": nil, "test_id": nil}).Error
+	if err != nil {
+		return err
+	}
+	steps := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&models.AiScore{}, "test_id IN (?)", []interface{}{testIDs}},
+		{&models.ItemResponse{}, "test_id IN (?)", []interface{}{testIDs}},
+		{&models.UserTest{}, "user_id = ?", []interface{}{userID}},
+		{&models.ChildProfile{}, "guardian_id = ?", []interface{}{userID}},
+		{&models.RefreshToken{}, "user_id = ?", []interface{}{userID}},
+		{&models.Session{}, "user_id = ?", []interface{}{userID}},
+		{&models.DeletionRequest{}, "user_id = ?", []interface{}{userID}},
+		{&models.User{}, "id = ?", []interface{}{userID}},
+	}
+	for _, step := range steps {
+		if err := tx.Where(step.query, step.args...).Delete(step.model).Error; err != nil {
+			return err
+		}
+	}
+	return scrubAuditIPs(tx, userID)
+}
@@ -0,0 +1,494 @@
+package handlers
+
+import (
+	"Dysec/internal/cat"
+	"Dysec/internal/models"
+	"Dysec/internal/scoring"
+	"Dysec/internal/testset"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errTestNotAdaptive = errors.New("test is not adaptive")
+	errItemNotPending  = errors.New("question is not the pending item")
+)
+
+// startAdaptiveTest membuat tes adaptif tanpa soal di awal; soal diambil satu per satu lewat next-item
+func (h *Handler) startAdaptiveTest(c *gin.Context, test models.UserTest) {
+	test.Mode = models.TestModeAdaptive
+	if err := h.DB.Create(&test).Error; err != nil {
+		log.Printf("ERROR: Could not create adaptive test record: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start test"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Adaptive test started successfully",
+		"test_id":    test.TestID,
+		"profile_id": test.ProfileID,
+		"mode":       test.Mode,
+		"age_band":   test.AgeBand,
+		"difficulty": test.Difficulty,
+		"stop_rule":  h.StopRule,
+	})
+}
+
+// findAdaptiveTest memuat tes adaptif milik user
+func (h *Handler) findAdaptiveTest(tx *gorm.DB, userID uint, testID uint64) (*models.UserTest, error) {
+	var test models.UserTest
+	if err := tx.Where("test_id = ? AND user_id = ?", testID, userID).First(&test).Error; err != nil {
+		return nil, err
+	}
+	if test.Mode != models.TestModeAdaptive {
+		return nil, errTestNotAdaptive
+	}
+	return &test, nil
+}
+
+func respondAdaptiveTestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Test not found or you do not have permission"})
+	case errors.Is(err, errTestNotAdaptive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Test is not adaptive; submit it with /tests/:id/submit"})
+	default:
+		log.Printf("ERROR: Could not load adaptive test: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load test"})
+	}
+}
+
+// adaptiveResponses memuat respons tes beserta parameter IRT soalnya, terurut sesuai posisi
+func adaptiveResponses(tx *gorm.DB, testID uint) ([]models.ItemResponse, []cat.Response, error) {
+	var rows []models.ItemResponse
+	if err := tx.Where("test_id = ?", testID).Order("position asc").Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return rows, nil, nil
+	}
+
+	ids := make([]string, len(rows))
+	for i, r := range rows {
+		ids[i] = r.QuestionID
+	}
+	var questions []models.Question
+	if err := tx.Where("question_id IN ?", ids).Find(&questions).Error; err != nil {
+		return nil, nil, err
+	}
+	items := map[string]cat.Item{}
+	for _, q := range questions {
+		items[q.QuestionID] = irtItem(q)
+	}
+
+	responses := make([]cat.Response, 0, len(rows))
+	for _, r := range rows {
+		if item, ok := items[r.QuestionID]; ok {
+			responses = append(responses, cat.Response{Item: item, Correct: r.Correct})
+		}
+	}
+	return rows, responses, nil
+}
+
+func irtItem(q models.Question) cat.Item {
+	return cat.Item{ID: q.QuestionID, A: q.IRTDiscrimination, B: q.IRTDifficulty}
+}
+
+// completeAdaptiveTest menandai tes selesai dan merangkum respons per subtes seperti tes biasa
+func completeAdaptiveTest(tx *gorm.DB, test *models.UserTest, rows []models.ItemResponse) error {
+	type tally struct {
+		Correct int `json:"correct"`
+		Wrong   int `json:"wrong"`
+		Total   int `json:"total"`
+	}
+	results := map[string]tally{}
+	for _, r := range rows {
+		t := results[r.SubtestName]
+		if r.Correct {
+			t.Correct++
+		} else {
+			t.Wrong++
+		}
+		t.Total++
+		results[r.SubtestName] = t
+	}
+	correctionJSON, _ := json.Marshal(results)
+
+	now := time.Now()
+	test.CompletedAt = &now
+	test.CorrectionResults = correctionJSON
+	test.PendingQuestionID, test.PendingQuestionVersion = "", 0
+	return tx.Model(test).Updates(map[string]interface{}{
+		"completed_at":             now,
+		"correction_results":       correctionJSON,
+		"pending_question_id":      "",
+		"pending_question_version": 0,
+	}).Error
+}
+
+func adaptiveDone(test *models.UserTest) gin.H {
+	return gin.H{
+		"test_id":            test.TestID,
+		"done":               true,
+		"ability":            test.Ability,
+		"ability_se":         test.AbilitySE,
+		"correction_results": test.CorrectionResults,
+	}
+}
+
+// NextItemHandler mengembalikan soal adaptif berikutnya: soal dengan information terbesar pada
+// estimasi ability saat ini dari subtes yang paling sedikit diberikan. Soal yang belum dijawab
+// dikembalikan lagi sehingga aman dipanggil ulang. Tes yang selesai dinilai dengan scorer.
+func (h *Handler) NextItemHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	testID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	test, err := h.findAdaptiveTest(h.DB, userID, testID)
+	if err != nil {
+		respondAdaptiveTestError(c, err)
+		return
+	}
+	if test.CompletedAt != nil {
+		h.respondAdaptiveDone(c, test)
+		return
+	}
+
+	rows, responses, err := adaptiveResponses(h.DB, test.TestID)
+	if err != nil {
+		log.Printf("ERROR: Could not load responses for test %d: %v", test.TestID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load test"})
+		return
+	}
+	theta, se := cat.Estimate(responses, cat.DefaultDifficulty(test.Difficulty))
+
+	var question models.Question
+	if test.PendingQuestionID != "" {
+		if err := h.DB.Where("question_id = ?", test.PendingQuestionID).First(&question).Error; err != nil {
+			log.Printf("ERROR: Pending question %s of test %d not found: %v", test.PendingQuestionID, test.TestID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load next item"})
+			return
+		}
+	} else {
+		if h.StopRule.Done(len(rows), se) {
+			h.respondCompleted(c, test, rows)
+			return
+		}
+
+		perSubtest := map[string]int{}
+		administered := make([]string, 0, len(rows))
+		for _, r := range rows {
+			perSubtest[r.SubtestName]++
+			administered = append(administered, r.QuestionID)
+		}
+		next, err := h.selectAdaptiveItem(theta, perSubtest, administered)
+		if err != nil {
+			log.Printf("ERROR: Could not load question bank: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load next item"})
+			return
+		}
+		if next == nil {
+			// Bank soal habis sebelum aturan berhenti terpenuhi
+			h.respondCompleted(c, test, rows)
+			return
+		}
+		question = *next
+
+		// Update bersyarat agar dua request bersamaan tidak menyajikan dua soal berbeda
+		result := h.DB.Model(&models.UserTest{}).
+			Where("test_id = ? AND pending_question_id = ''", test.TestID).
+			Updates(map[string]interface{}{"pending_question_id": question.QuestionID, "pending_question_version": question.Version})
+		if result.Error != nil {
+			log.Printf("ERROR: Could not set pending item for test %d: %v", test.TestID, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load next item"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another item is already pending, request next-item again"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"test_id":    test.TestID,
+		"done":       false,
+		"position":   len(rows) + 1,
+		"subtest":    question.SubtestName,
+		"question":   question.QuestionData,
+		"ability":    theta,
+		"ability_se": se,
+	})
+}
+
+// adaptiveCandidatePool adalah jumlah soal per subtes yang diambil dari database untuk dipilih cat.SelectNext
+const adaptiveCandidatePool = 50
+
+// selectAdaptiveItem memilih soal berikutnya dari subtes yang paling sedikit diberikan (cat.BalanceOrder).
+// Kandidat diambil di SQL: soal aktif yang belum diberikan dengan difficulty terdekat ke theta, karena
+// information item 2PL terbesar di sekitar b. Mengembalikan nil jika semua subtes sudah habis.
+func (h *Handler) selectAdaptiveItem(theta float64, perSubtest map[string]int, administered []string) (*models.Question, error) {
+	for _, subtest := range cat.BalanceOrder(testset.ItemSubtests, perSubtest) {
+		query := h.DB.Where("subtest_name = ? AND status = ?", subtest, models.QuestionStatusActive)
+		if len(administered) > 0 {
+			query = query.Where("question_id NOT IN ?", administered)
+		}
+		var pool []models.Question
+		err := query.
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: "ABS(irt_difficulty - ?), question_id", Vars: []interface{}{theta}}}).
+			Limit(adaptiveCandidatePool).
+			Find(&pool).Error
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]cat.Item, len(pool))
+		byID := map[string]models.Question{}
+		for i, q := range pool {
+			candidates[i] = irtItem(q)
+			byID[q.QuestionID] = q
+		}
+		if next, ok := cat.SelectNext(theta, candidates, nil); ok {
+			question := byID[next.ID]
+			return &question, nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *Handler) respondCompleted(c *gin.Context, test *models.UserTest, rows []models.ItemResponse) {
+	if err := completeAdaptiveTest(h.DB, test, rows); err != nil {
+		log.Printf("ERROR: Could not complete adaptive test %d: %v", test.TestID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete test"})
+		return
+	}
+	h.respondAdaptiveDone(c, test)
+}
+
+// respondAdaptiveDone menilai tes adaptif yang sudah selesai lalu mengirim hasil akhirnya. Jika scorer
+// gagal, tes tetap selesai dan next-item bisa dipanggil lagi untuk mencoba penilaian ulang.
+func (h *Handler) respondAdaptiveDone(c *gin.Context, test *models.UserTest) {
+	var score models.AiScore
+	err := h.DB.Where("test_id = ?", test.TestID).First(&score).Error
+	if err == nil {
+		done := adaptiveDone(test)
+		done["ai_results"] = score.RawResponse
+		c.JSON(http.StatusOK, done)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("ERROR: Could not load AI score for test %d: %v", test.TestID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load test score"})
+		return
+	}
+
+	result, err := h.scoreAdaptiveTest(c, test)
+	if err != nil {
+		log.Printf("ERROR: Scoring failed for test %d: %v", test.TestID, err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":              "Scoring service is unavailable, request next-item again later",
+			"correction_results": test.CorrectionResults,
+		})
+		return
+	}
+	done := adaptiveDone(test)
+	done["ai_results"] = result
+	c.JSON(http.StatusOK, done)
+}
+
+// scoreAdaptiveTest menilai tes adaptif dengan scorer yang sama seperti tes biasa. Akurasi per subtes
+// dari respons yang diberikan dan waktu reaksi dari median ResponseTimeMs. Tes adaptif tidak memuat
+// tugas simple reaction time, sehingga Srt bernilai 0.
+func (h *Handler) scoreAdaptiveTest(c *gin.Context, test *models.UserTest) (*scoring.Result, error) {
+	var rows []models.ItemResponse
+	if err := h.DB.Where("test_id = ?", test.TestID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var features scoring.Features
+	if test.Age != nil {
+		features.Age = *test.Age
+	}
+	if test.ProfileID != nil {
+		profile, err := h.findOwnedProfile(test.UserID, *test.ProfileID)
+		if err != nil {
+			return nil, err
+		}
+		features.Age = profile.AgeAt(test.CreatedAt)
+	}
+	features.DotRt, features.DotAcc = subtestPerformance(rows, testset.SubtestDot)
+	features.StroopRt, features.StroopAcc = subtestPerformance(rows, testset.SubtestStroop)
+	features.AddRt, features.AddAcc = subtestPerformance(rows, testset.SubtestAddition)
+	features.MultRt, features.MultAcc = subtestPerformance(rows, testset.SubtestMultiplication)
+	features.SubsRt, features.SubsAcc = subtestPerformance(rows, testset.SubtestSubstitution)
+
+	result, rawResponse, err := h.Scorer.Score(c.Request.Context(), features)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveAiScore(h.DB, test.TestID, result, rawResponse); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// subtestPerformance menghitung median waktu respons dan proporsi benar satu subtes
+func subtestPerformance(rows []models.ItemResponse, subtest string) (rt, acc float64) {
+	var times []int
+	correct, total := 0, 0
+	for _, r := range rows {
+		if r.SubtestName != subtest {
+			continue
+		}
+		total++
+		if r.Correct {
+			correct++
+		}
+		if r.ResponseTimeMs > 0 {
+			times = append(times, r.ResponseTimeMs)
+		}
+	}
+	if total > 0 {
+		acc = float64(correct) / float64(total)
+	}
+	if n := len(times); n > 0 {
+		sort.Ints(times)
+		rt = float64(times[n/2])
+		if n%2 == 0 {
+			rt = float64(times[n/2-1]+times[n/2]) / 2
+		}
+	}
+	return rt, acc
+}
+
+// servedQuestion mengembalikan isi soal pada versi saat ditampilkan. Jika soal sudah diedit sejak
+// itu, kunci jawaban dan parameter IRT diambil dari snapshot QuestionVersion versi tersebut.
+// version 0 (tes lama tanpa versi tercatat) berarti versi saat ini.
+func servedQuestion(tx *gorm.DB, question models.Question, version int) (models.Question, error) {
+	if version == 0 || version == question.Version {
+		return question, nil
+	}
+	var snapshot models.QuestionVersion
+	err := tx.Where("question_ref_id = ? AND version = ?", question.ID, version).First(&snapshot).Error
+	if err != nil {
+		return question, fmt.Errorf("question %s version %d: %w", question.QuestionID, version, err)
+	}
+	question.Version = snapshot.Version
+	question.SubtestName = snapshot.SubtestName
+	question.QuestionData = snapshot.QuestionData
+	question.AnswerData = snapshot.AnswerData
+	question.Difficulty = snapshot.Difficulty
+	question.IRTDiscrimination = snapshot.IRTDiscrimination
+	question.IRTDifficulty = snapshot.IRTDifficulty
+	return question, nil
+}
+
+// SubmitResponseHandler mencatat jawaban untuk soal adaptif yang sedang ditampilkan lalu memperbarui
+// estimasi ability. Kunci jawaban tidak dikembalikan ke klien.
+func (h *Handler) SubmitResponseHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	testID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var req AdaptiveResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: question_id is required"})
+		return
+	}
+
+	var test *models.UserTest
+	var done bool
+	var position int
+	var theta, se float64
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if test, err = h.findAdaptiveTest(tx, userID, testID); err != nil {
+			return err
+		}
+		if test.CompletedAt != nil || test.PendingQuestionID != req.QuestionID {
+			return errItemNotPending
+		}
+
+		var question models.Question
+		if err := tx.Where("question_id = ?", req.QuestionID).First(&question).Error; err != nil {
+			return err
+		}
+		// Jawaban dinilai dengan versi soal saat ditampilkan, bukan versi setelah diedit admin
+		if question, err = servedQuestion(tx, question, test.PendingQuestionVersion); err != nil {
+			return err
+		}
+
+		rows, responses, err := adaptiveResponses(tx, test.TestID)
+		if err != nil {
+			return err
+		}
+		position = len(rows) + 1
+		correct := strings.TrimSpace(req.Answer) == question.AnswerData
+		responses = append(responses, cat.Response{Item: irtItem(question), Correct: correct})
+		theta, se = cat.Estimate(responses, cat.DefaultDifficulty(test.Difficulty))
+
+		response := models.ItemResponse{
+			TestID:          test.TestID,
+			QuestionID:      question.QuestionID,
+			SubtestName:     question.SubtestName,
+			QuestionVersion: question.Version,
+			Position:        position,
+			Answer:          req.Answer,
+			Correct:         correct,
+			ResponseTimeMs:  req.ResponseTimeMs,
+			AbilityAfter:    &theta,
+			SEAfter:         &se,
+		}
+		if err := tx.Create(&response).Error; err != nil {
+			return err
+		}
+
+		// Kosongkan soal pending hanya jika masih soal yang sama (mencegah jawaban ganda)
+		result := tx.Model(&models.UserTest{}).
+			Where("test_id = ? AND pending_question_id = ?", test.TestID, req.QuestionID).
+			Updates(map[string]interface{}{"ability": theta, "ability_se": se, "pending_question_id": "", "pending_question_version": 0})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errItemNotPending
+		}
+		test.Ability, test.AbilitySE, test.PendingQuestionID = &theta, &se, ""
+
+		done = h.StopRule.Done(position, se)
+		if done {
+			return completeAdaptiveTest(tx, test, append(rows, response))
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errItemNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Question is not the current item of this test"})
+			return
+		}
+		respondAdaptiveTestError(c, err)
+		return
+	}
+
+	if done {
+		h.respondAdaptiveDone(c, test)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"test_id":    test.TestID,
+		"done":       false,
+		"position":   position,
+		"ability":    theta,
+		"ability_se": se,
+	})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"Dysec/internal/ai"
+	"Dysec/internal/models"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) ListUsersHandler(c *gin.Context) {
+	query := h.DB.Order("id asc")
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		log.Printf("ERROR: Could not fetch users: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+func (h *Handler) UpdateUserRoleHandler(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !models.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role", "allowed_roles": models.Roles})
+		return
+	}
+
+	userIDClaim, _ := c.Get("user_id")
+	if uint(userIDClaim.(float64)) == uint(targetID) && req.Role != models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Admins cannot remove their own admin role"})
+		return
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, targetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	// Role dibawa access token, jadi semua session user dicabut saat role berubah agar
+	// token lama (mis. milik admin yang diturunkan) langsung berhenti berlaku
+	previousRole := user.Role
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", req.Role).Error; err != nil {
+			return err
+		}
+		if previousRole == req.Role {
+			return nil
+		}
+		return revokeSessions(tx, "user_id = ?", user.ID)
+	})
+	if err != nil {
+		log.Printf("ERROR: Could not update role for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	audit(h.DB, c, uint(userIDClaim.(float64)), "user.role_changed", "user", user.ID, gin.H{"from": previousRole, "to": req.Role})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role updated successfully",
+		"user":    user,
+	})
+}
+
+// AIStatusHandler menampilkan state circuit breaker AI untuk monitoring
+func (h *Handler) AIStatusHandler(c *gin.Context) {
+	reporter, ok := h.AIService.(ai.StatsReporter)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"circuit_breaker": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"circuit_breaker": reporter.Stats()})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// audit menulis jejak audit. Kegagalan hanya di-log agar tidak menggagalkan aksi utama.
+func audit(db *gorm.DB, c *gin.Context, actorID uint, action, targetType string, targetID interface{}, details gin.H) {
+	if err := auditTx(db, c, actorID, action, targetType, targetID, details); err != nil {
+		log.Printf("ERROR: Could not write audit log %s for %s %v: %v", action, targetType, targetID, err)
+	}
+}
+
+// auditTx menulis jejak audit dan mengembalikan error-nya, untuk aksi di dalam transaksi yang
+// tidak boleh selesai tanpa jejak audit. Di Postgres INSERT yang gagal juga membatalkan transaksi.
+func auditTx(tx *gorm.DB, c *gin.Context, actorID uint, action, targetType string, targetID interface{}, details gin.H) error {
+	entry := models.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		IPAddress:  c.ClientIP(),
+	}
+	if actorID != 0 {
+		entry.ActorID = &actorID
+	}
+	if details != nil {
+		entry.Details, _ = json.Marshal(details)
+	}
+	return tx.Create(&entry).Error
+}
+
+// auditEmailKeyFromConfig membaca kunci HMAC email audit dari env AUDIT_EMAIL_HMAC_KEY (audit.email_hmac_key)
+func auditEmailKeyFromConfig() []byte {
+	key := viper.GetString("audit.email_hmac_key")
+	if key == "" {
+		log.Println("WARNING: audit.email_hmac_key is not set; deleted accounts are audited without an email digest")
+		return nil
+	}
+	return []byte(key)
+}
+
+// emailDigest mengembalikan HMAC-SHA256 email dengan kunci audit. Tanpa kunci rahasia hash email
+// bisa ditebak dari daftar email, jadi tanpa kunci tidak ada digest yang disimpan.
+func emailDigest(key []byte, email string) string {
+	if len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// scrubAuditIPs mengosongkan alamat IP di semua jejak audit yang dibuat oleh atau tentang user
+func scrubAuditIPs(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.AuditLog{}).
+		Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userID, "user", fmt.Sprint(userID)).
+		Update("ip_address", "").Error
+}
@@ -1,25 +1,38 @@
 package handlers
 
 import (
+	"Dysec/internal/ageband"
 	"Dysec/internal/ai"
+	"Dysec/internal/auth"
+	"Dysec/internal/cat"
+	"Dysec/internal/itemgen"
+	"Dysec/internal/itemstats"
 	"Dysec/internal/models"
+	"Dysec/internal/prompts"
+	"Dysec/internal/questionbank"
+	"Dysec/internal/scoring"
+	"Dysec/internal/testset"
+	"Dysec/internal/usage"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"google.golang.org/api/idtoken"
 	"gorm.io/gorm"
-	//"gorm.io/gorm/clause"
+	"gorm.io/gorm/clause"
 )
 
 type SubtestPayload struct {
 	Answers         map[string]string      `json:"answers"`
 	PerformanceData map[string]interface{} `json:"performance_data"`
+	// ResponseTimesMs adalah waktu jawab per question_id (opsional), dipakai untuk analisis soal
+	ResponseTimesMs map[string]int `json:"response_times_ms"`
 }
 type SubmitRequest struct {
 	SimpleReactionTime SubtestPayload `json:"simple_reaction_time"`
@@ -45,31 +58,48 @@ type AIResponse struct {
 
 type Handler struct {
 	DB        *gorm.DB
-	AIService *ai.Service
+	AIService ai.Generator
+	Tokens    *auth.TokenManager
+	Verifier  auth.TokenVerifier
+	Prompts   *prompts.Renderer
+	Scorer    scoring.Scorer
+	Usage     *usage.Ledger
+	AgeBands  ageband.Table
+	StopRule  cat.StopRule
+	ItemStats *itemstats.Analyzer
+
+	// AuditEmailKey adalah kunci HMAC untuk digest email di jejak audit penghapusan akun
+	AuditEmailKey []byte
+
+	// LiveGeneration mengizinkan StartSessionHandler memanggil AI saat stok bank soal kurang,
+	// paling lama AIRequestBudget untuk seluruh retry dan perbaikan output
+	LiveGeneration  bool
+	AIRequestBudget time.Duration
 }
 
-type AIRequestData struct {
-	Age       int     `json:"age"`
-	Srt       float64 `json:"srt"`
-	DotRt     float64 `json:"dot_rt"`
-	DotAcc    float64 `json:"dot_acc"`
-	StroopRt  float64 `json:"stroop_rt"`
-	StroopAcc float64 `json:"stroop_acc"`
-	AddRt     float64 `json:"add_rt"`
-	AddAcc    float64 `json:"add_acc"`
-	MultRt    float64 `json:"mult_rt"`
-	MultAcc   float64 `json:"mult_acc"`
-	SubsRt    float64 `json:"subs_rt"`
-	SubsAcc   float64 `json:"subs_acc"`
-}
-
-func New(db *gorm.DB, aiService *ai.Service) Handler {
-	return Handler{DB: db, AIService: aiService}
+func New(db *gorm.DB, aiService ai.Generator, tokens *auth.TokenManager, verifier auth.TokenVerifier, prompts *prompts.Renderer, scorer scoring.Scorer, ledger *usage.Ledger, ageBands ageband.Table) Handler {
+	return Handler{
+		DB:              db,
+		AIService:       aiService,
+		Tokens:          tokens,
+		Verifier:        verifier,
+		Prompts:         prompts,
+		Scorer:          scorer,
+		Usage:           ledger,
+		AgeBands:        ageBands,
+		StopRule:        cat.StopRuleFromConfig(),
+		ItemStats:       itemstats.NewAnalyzerFromConfig(db),
+		AuditEmailKey:   auditEmailKeyFromConfig(),
+		LiveGeneration:  true,
+		AIRequestBudget: ai.RequestBudgetFromConfig(),
+	}
 }
 
 func (h *Handler) GoogleAuthHandler(c *gin.Context) {
 	var req struct {
-		Token string `json:"token" binding:"required"`
+		Token      string `json:"token" binding:"required"`
+		DeviceID   string `json:"device_id"`
+		DeviceName string `json:"device_name"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: token is required"})
@@ -77,18 +107,21 @@ func (h *Handler) GoogleAuthHandler(c *gin.Context) {
 	}
 
 	// Verifikasi token untuk memastikan login valid dan mendapatkan data user
-	payload, err := idtoken.Validate(c.Request.Context(), req.Token, "")
+	identity, err := h.Verifier.Verify(c.Request.Context(), req.Token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Google ID Token"})
+		log.Printf("WARNING: Rejected identity token: %v", err)
+		code, message := auth.Rejection(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message, "code": code})
 		return
 	}
 
 	// Ambil data dari token dan simpan/update user di database
 	user := models.User{
-		GoogleID:   payload.Subject,
-		Email:      payload.Claims["email"].(string),
-		Name:       payload.Claims["name"].(string),
-		PictureURL: payload.Claims["picture"].(string),
+		GoogleID:   identity.Subject,
+		Email:      identity.Email,
+		Name:       identity.Name,
+		PictureURL: identity.Picture,
+		Role:       models.RoleStudent,
 	}
 
 	if err := h.DB.Where(models.User{GoogleID: user.GoogleID}).FirstOrCreate(&user).Error; err != nil {
@@ -96,240 +129,229 @@ func (h *Handler) GoogleAuthHandler(c *gin.Context) {
 		return
 	}
 
-	// Tidak ada lagi app_token yang dikembalikan
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User authenticated successfully. Use the provided ID token for subsequent requests.",
-		"user":    user,
-	})
-}
-
-/*
-// GoogleAuthHandler versi produksi dengan verifikasi token Google
-
-	func (h *Handler) GoogleAuthHandler(c *gin.Context) {
-		var req struct {
-			Token string `json:"token" binding:"required"`
-		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: token is required"})
-			return
+	if user.Role != models.RoleAdmin && auth.IsAdminEmail(user.Email) {
+		if err := h.DB.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+			log.Printf("ERROR: Could not promote bootstrap admin %s: %v", user.Email, err)
 		}
+	}
 
-		payload, err := idtoken.Validate(c.Request.Context(), req.Token, "")
+	// Tukar Google ID token dengan access token + refresh token milik aplikasi, satu session per perangkat
+	var tokens gin.H
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		session, err := startSession(tx, c, user.ID, req.DeviceID, req.DeviceName)
 		if err != nil {
-			log.Printf("ERROR: Invalid Google ID Token: %v\n", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Google ID Token"})
-			return
+			return err
 		}
+		tokens, err = h.issueTokens(tx, user, session)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Could not issue tokens for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
 
-		googleID := payload.Subject
-		email := payload.Claims["email"].(string)
-		name := payload.Claims["name"].(string)
-		pictureURL := payload.Claims["picture"].(string)
+	tokens["message"] = "User authenticated successfully"
+	tokens["user"] = user
+	c.JSON(http.StatusOK, tokens)
+}
 
-		user := models.User{
-			GoogleID:   googleID,
-			Email:      email,
-			Name:       name,
-			PictureURL: pictureURL,
-		}
+func (h *Handler) StartSessionHandler(c *gin.Context) {
+	userIDClaim, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+	userID := uint(userIDClaim.(float64))
 
-		if err := h.DB.Where(models.User{GoogleID: googleID}).FirstOrCreate(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not process user"})
-			return
-		}
+	// Body bersifat opsional; profile_id dipakai bila tes dikerjakan oleh profil anak
+	var req StartTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body structure"})
+		return
+	}
 
-		claims := jwt.MapClaims{
-			"user_id": user.ID,
-			"email":   user.Email,
-			"exp":     time.Now().Add(time.Hour * 72).Unix(),
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, err := token.SignedString([]byte(h.JWTSecret))
+	// Usia diambil dari profil anak bila ada, selain itu dari age/grade di request
+	age, grade := req.Age, req.Grade
+	if req.ProfileID != nil {
+		profile, err := h.findOwnedProfile(userID, *req.ProfileID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+			h.respondProfileError(c, err)
 			return
 		}
+		profileAge := profile.AgeAt(time.Now())
+		age, grade = &profileAge, profile.Grade
+	}
+	if age != nil && (*age < 3 || *age > 99) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid age"})
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message":   "User authenticated successfully",
-			"app_token": tokenString,
-		})
+	// Kelompok usia menentukan tingkat kesulitan untuk bank soal, prompt AI, dan generator prosedural.
+	// Tanpa usia/kelas, bank soal diambil dari semua tingkat dan soal baru dibuat dengan tingkat medium.
+	band, hasBand := h.AgeBands.Resolve(age, grade)
+	difficulty := ""
+	if hasBand {
+		difficulty = band.Difficulty
 	}
-*/
-func (h *Handler) StartSessionHandler(c *gin.Context) {
-	userIDClaim, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+
+	// Organisasi dicatat di tes agar API integrasi tidak ikut membuka tes dari sebelum user bergabung
+	var user models.User
+	if err := h.DB.Select("id", "organization_id").First(&user, userID).Error; err != nil {
+		log.Printf("ERROR: Could not fetch user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start test"})
 		return
 	}
-	userID := uint(userIDClaim.(float64))
+
+	switch req.Mode {
+	case "", models.TestModeFixed:
+	case models.TestModeAdaptive:
+		// Tes adaptif memilih soal dari bank satu per satu, prior ability mengikuti kelompok usia
+		h.startAdaptiveTest(c, models.UserTest{
+			UserID:         userID,
+			ProfileID:      req.ProfileID,
+			OrganizationID: user.OrganizationID,
+			Age:            age,
+			AgeBand:        band.ID,
+			Difficulty:     difficulty,
+		})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode, use fixed or adaptive"})
+		return
+	}
+
+	generationDifficulty := difficulty
+	if generationDifficulty == "" {
+		generationDifficulty = models.DifficultyMedium
+	}
 
 	var finalSubtestsData json.RawMessage
-	var finalError error
-
-	fallbackQuestionCount := map[string]int{
-		"dot":            2,
-		"stroop":         2,
-		"addition":       2,
-		"multiplication": 2,
-		"substitution":   2,
-	}
-
-	prompt := `Buatkan satu set soal tes untuk deteksi gejala diskalkulia yang terdiri dari 6 subtes: simple_reaction_time, dot, stroop, addition, multiplication, dan substitution.
-
-Output HARUS dalam format JSON tunggal dengan satu key utama "subtests".
-Setiap objek subtes HARUS memiliki dua key: "questions" dan "answer_key".
-Setiap soal di dalam array "questions" HARUS memiliki key "question_id" dengan nilai string yang unik.
-
-- Untuk subtes tanpa pertanyaan (simple_reaction_time), "questions" harus berupa array kosong [].
-- Untuk subtes dengan pertanyaan, "questions" harus berupa array of objects, dan "answer_key" harus berupa object map dimana key-nya adalah "question_id" yang sesuai.
-
-Buatkan 2 soal untuk setiap subtes "dot", "stroop", "addition", "multiplication", dan "substitution".
-
-Contoh Format yang WAJIB diikuti:
-{
-  "subtests": {
-    "addition": {
-        "questions": [
-            {"question_id": "add_1", "type": "text_input", "text": "Berapa 12 + 9?"},
-            {"question_id": "add_2", "type": "text_input", "text": "Berapa 7 + 6?"}
-        ],
-        "answer_key": {
-            "add_1": "21",
-            "add_2": "13"
-        }
-    }
-  }
-}`
-	// Alur 1: Coba panggil AI Service
-	aiResponseJSON, err := h.AIService.GenerateTestFromPrompt(prompt)
-	if err == nil {
-		log.Println("INFO: AI call successful. Starting to process response...")
-
-		startIndex := strings.Index(aiResponseJSON, "{")
-		endIndex := strings.LastIndex(aiResponseJSON, "}")
-
-		if startIndex != -1 && endIndex != -1 && endIndex > startIndex {
-			cleanJSON := aiResponseJSON[startIndex : endIndex+1]
-			log.Println("INFO: Successfully cleaned JSON response.")
-
-			var parsedAIResponse map[string]json.RawMessage
-			if err := json.Unmarshal([]byte(cleanJSON), &parsedAIResponse); err == nil {
-				log.Println("INFO: Successfully unmarshaled root JSON.")
-
-				if subtestsJSON, ok := parsedAIResponse["subtests"]; ok {
-					log.Println("INFO: 'subtests' key found. Proceeding to save questions.")
-					finalSubtestsData = subtestsJSON
-
-					var subtests map[string]struct {
-						Questions []map[string]interface{} `json:"questions"`
-						AnswerKey map[string]interface{}   `json:"answer_key"`
-					}
-					if err := json.Unmarshal(subtestsJSON, &subtests); err == nil {
-						log.Printf("INFO: Parsed %d subtests. Looping to save individual questions...", len(subtests))
-						for subtestName, data := range subtests {
-							log.Printf("INFO: Processing subtest: %s", subtestName)
-							for _, q := range data.Questions {
-								qID, id_ok := q["question_id"].(string)
-								if !id_ok {
-									log.Println("WARNING: question_id not found or not a string, skipping question.")
-									continue
-								}
-
-								var existingQuestion models.Question
-								dbErr := h.DB.Where("question_id = ?", qID).First(&existingQuestion).Error
-
-								if errors.Is(dbErr, gorm.ErrRecordNotFound) {
-									answer := fmt.Sprintf("%v", data.AnswerKey[qID])
-									qData, _ := json.Marshal(q)
-
-									newQuestion := models.Question{
-										SubtestName:  subtestName,
-										QuestionID:   qID,
-										QuestionData: qData,
-										AnswerData:   answer,
-									}
-									if createErr := h.DB.Create(&newQuestion).Error; createErr != nil {
-										log.Printf("ERROR: Failed to create new question %s: %v", qID, createErr)
-									} else {
-										log.Printf("INFO: Successfully saved new question %s to database.", qID)
-									}
-								} else if dbErr == nil {
-									log.Printf("INFO: Question %s already exists in DB, skipping.", qID)
-								} else {
-									log.Printf("ERROR: DB check failed for question %s: %v", qID, dbErr)
-								}
-							}
-						}
-					} else {
-						log.Printf("ERROR: Failed to unmarshal the 'subtests' block: %v", err)
-						finalError = fmt.Errorf("failed to unmarshal subtests block: %w", err)
-					}
-				} else {
-					log.Println("ERROR: 'subtests' key was not found in the parsed JSON.")
-					finalError = fmt.Errorf("key 'subtests' not found in AI response")
-				}
-			} else {
-				log.Printf("ERROR: Failed to unmarshal the cleaned root JSON: %v", err)
-				finalError = fmt.Errorf("failed to parse AI response JSON: %w", err)
-			}
-		} else {
-			log.Println("ERROR: Could not find valid JSON object in AI response string.")
-			finalError = fmt.Errorf("could not find valid JSON object in AI response")
-		}
+	var promptTemplate *prompts.Template
+	var usages []ai.Usage
+	var usedSeed *int64
+	source := "bank"
+
+	// Seed untuk generator prosedural. Seed dari request membuat seluruh tes prosedural
+	// sehingga sesi yang sama bisa direproduksi persis. Seed hanya disimpan di tes yang seluruhnya
+	// prosedural: soal bank dipilih acak oleh database dan tidak bisa direproduksi dari seed.
+	seed := time.Now().UnixNano()
+	var bankSubtests testset.TestSet
+	if req.Seed != nil {
+		seed = *req.Seed
+		bankSubtests = testset.NewTestSet()
 	} else {
-		finalError = err
+		// Alur 1: Susun tes langsung dari bank soal yang dijaga stoknya oleh worker
+		var complete bool
+		var err error
+		bankSubtests, complete, err = questionbank.Assemble(h.DB, testset.DefaultCounts, difficulty)
+		if err != nil {
+			log.Printf("ERROR: Could not assemble test from question bank: %v", err)
+			bankSubtests = testset.NewTestSet()
+		}
+		if complete {
+			finalSubtestsData, _ = json.Marshal(bankSubtests)
+		}
 	}
 
-	// Alur 2: Jika ada error dari AI ATAU dari parsing, bangun tes fallback
-	if finalError != nil {
-		log.Printf("WARNING: An error occurred (%v). Building fallback test from database.", finalError)
+	// Alur 2: Jika stok bank kurang, buat soal langsung dengan AI (opsional, tests.live_generation).
+	// User yang melewati budget AI bulanannya hanya mendapat soal dari bank.
+	// Context request dipakai agar panggilan ke AI ikut berhenti jika klien membatalkan request,
+	// dengan batas waktu total AIRequestBudget agar request tidak menunggu provider yang lambat.
+	if finalSubtestsData == nil && req.Seed == nil && h.LiveGeneration && h.withinAIBudget(c, userID) {
+		log.Println("INFO: Question bank stock is insufficient. Generating test with AI...")
+		var generated *testset.Generated
+		prompt, tmpl, finalError := h.Prompts.TestPrompt(testset.DefaultCounts, generationDifficulty, band.ID)
+		if finalError == nil {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), h.AIRequestBudget)
+			generated, usages, finalError = ai.GenerateTest(ctx, h.AIService, prompt, testset.DefaultCounts)
+			cancel()
+		}
+		if finalError == nil {
+			// Save mengganti ID lokal dari AI dengan ID bank soal sebelum tes disusun,
+			// agar answer key tes sama dengan soal yang tersimpan
+			questionbank.Save(h.DB, generated, generationDifficulty)
+			var set testset.TestSet
+			set, finalError = generated.TestSet()
+			if finalError == nil {
+				finalSubtestsData, finalError = json.Marshal(set)
+			}
+		}
 
-		fallbackSubtests := make(map[string]interface{})
-		for subtestName, count := range fallbackQuestionCount {
-			var randomQuestions []models.Question
-			h.DB.Where("subtest_name = ?", subtestName).Order("RANDOM()").Limit(count).Find(&randomQuestions)
+		if finalError == nil {
+			source = "ai"
+			promptTemplate = tmpl
+		} else if errors.Is(finalError, ai.ErrCircuitOpen) {
+			log.Println("INFO: AI circuit breaker is open. Building test from database and procedural items.")
+		} else {
+			log.Printf("WARNING: An error occurred (%v). Building test from database and procedural items.", finalError)
+		}
+	}
 
-			questionsForJSON := []json.RawMessage{}
-			answersForJSON := make(map[string]string)
-			for _, q := range randomQuestions {
-				questionsForJSON = append(questionsForJSON, q.QuestionData)
-				answersForJSON[q.QuestionID] = q.AnswerData
-			}
-			fallbackSubtests[subtestName] = map[string]interface{}{
-				"questions":  questionsForJSON,
-				"answer_key": answersForJSON,
-			}
+	// Alur 3: Tanpa AI, lengkapi soal bank yang kurang dengan generator prosedural agar tes tidak pernah kosong
+	if finalSubtestsData == nil {
+		source = "bank_partial"
+		bankItems := 0
+		for _, subtest := range testset.ItemSubtests {
+			bankItems += bankSubtests.Count(subtest)
+		}
+		added, err := itemgen.New(seed, generationDifficulty).Fill(bankSubtests, testset.DefaultCounts)
+		if err != nil {
+			log.Printf("ERROR: Could not generate procedural items: %v", err)
 		}
-		fallbackSubtests["simple_reaction_time"] = map[string]interface{}{
-			"questions":  []interface{}{},
-			"answer_key": map[string]string{},
+		if added > 0 {
+			source = "procedural"
+			if bankItems == 0 {
+				usedSeed = &seed
+			}
 		}
+		finalSubtestsData, _ = json.Marshal(bankSubtests)
+	}
 
-		finalSubtestsData, _ = json.Marshal(fallbackSubtests)
+	questionVersions, err := snapshotQuestionVersions(h.DB, finalSubtestsData)
+	if err != nil {
+		log.Printf("ERROR: Could not record question versions: %v", err)
 	}
 
 	// Lanjutkan alur dengan data yang sudah didapat
 	test := models.UserTest{
-		UserID:    userID,
-		AnswerKey: finalSubtestsData,
+		UserID:           userID,
+		ProfileID:        req.ProfileID,
+		OrganizationID:   user.OrganizationID,
+		AnswerKey:        finalSubtestsData,
+		Seed:             usedSeed,
+		QuestionVersions: questionVersions,
+		Age:              age,
+		AgeBand:          band.ID,
+		Difficulty:       difficulty,
+	}
+	if promptTemplate != nil {
+		test.PromptTemplateID = promptTemplate.ID
+		test.PromptVersion = promptTemplate.Version
 	}
 
 	if err := h.DB.Create(&test).Error; err != nil {
+		h.Usage.Record(&userID, nil, usage.PurposeTestStart, usages)
 		log.Printf("ERROR: Could not create test record: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start test"})
 		return
 	}
+	h.Usage.Record(&userID, &test.TestID, usage.PurposeTestStart, usages)
 
 	finalResponse, _ := json.Marshal(map[string]interface{}{
-		"message":  "Test started successfully",
-		"test_id":  test.TestID,
-		"subtests": finalSubtestsData,
+		"message":    "Test started successfully",
+		"test_id":    test.TestID,
+		"profile_id": test.ProfileID,
+		"source":     source,
+		"seed":       test.Seed,
+		"age_band":   test.AgeBand,
+		"difficulty": test.Difficulty,
+		"subtests":   finalSubtestsData,
 	})
 
 	c.Data(http.StatusOK, "application/json; charset=utf-8", finalResponse)
 }
+
 func (h *Handler) SubmitTestHandler(c *gin.Context) {
 
 	testIDStr := c.Param("id")
@@ -348,6 +370,10 @@ func (h *Handler) SubmitTestHandler(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Test not found or you do not have permission"})
 		return
 	}
+	if test.Mode == models.TestModeAdaptive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Adaptive tests are answered item by item with /tests/:id/responses"})
+		return
+	}
 
 	var allSubtestsFromDB map[string]map[string]interface{}
 	if err := json.Unmarshal(test.AnswerKey, &allSubtestsFromDB); err != nil {
@@ -391,7 +417,19 @@ func (h *Handler) SubmitTestHandler(c *gin.Context) {
 	test.CorrectionResults = correctionJSON
 	h.DB.Save(&test)
 
-	var aiRequest AIRequestData
+	// Jawaban per soal disimpan untuk analisis soal; kegagalan tidak menggagalkan penilaian
+	payloads := map[string]SubtestPayload{
+		testset.SubtestDot:            req.Dot,
+		testset.SubtestStroop:         req.Stroop,
+		testset.SubtestAddition:       req.Addition,
+		testset.SubtestMultiplication: req.Multiplication,
+		testset.SubtestSubstitution:   req.Substitution,
+	}
+	if err := saveItemResponses(h.DB, &test, payloads); err != nil {
+		log.Printf("ERROR: Could not save item responses for test %d: %v", test.TestID, err)
+	}
+
+	var aiRequest scoring.Features
 
 	// Fungsi bantu untuk konversi yang aman
 	getFloat := func(data map[string]interface{}, key string) float64 {
@@ -401,8 +439,19 @@ func (h *Handler) SubmitTestHandler(c *gin.Context) {
 		return 0.0
 	}
 
-	// Lakukan transformasi dengan aman
+	// Lakukan transformasi dengan aman. Usia diambil dari profil anak bila tes milik profil.
 	aiRequest.Age = int(getFloat(req.SimpleReactionTime.PerformanceData, "age"))
+	if test.Age != nil {
+		aiRequest.Age = *test.Age
+	}
+	if test.ProfileID != nil {
+		profile, err := h.findOwnedProfile(userID, *test.ProfileID)
+		if err != nil {
+			h.respondProfileError(c, err)
+			return
+		}
+		aiRequest.Age = profile.AgeAt(test.CreatedAt)
+	}
 	aiRequest.Srt = getFloat(req.SimpleReactionTime.PerformanceData, "median_reaction_time")
 	aiRequest.DotRt = getFloat(req.Dot.PerformanceData, "median_reaction_time")
 	if total := allCorrectionResults["dot"].Total; total > 0 {
@@ -425,38 +474,70 @@ func (h *Handler) SubmitTestHandler(c *gin.Context) {
 		aiRequest.SubsAcc = float64(allCorrectionResults["substitution"].Correct) / float64(total)
 	}
 
-	log.Printf("INFO: Simulating call to AI for scoring with data: %+v\n", aiRequest)
-	simulatedAIResponseStr := `{
-		"diagnosis": 2, "label": {"diagnosis": {"0": "Normal", "1": "Diskalkulia", "2": "Keterampilan Aritmatika yang Buruk"}}, "probabilitas": {"0": 0.04, "1": 0.43, "2": 0.43}, "skor": [3.41, 3.3, 5.23, 1.58, 1.87]
-	}`
-
-	var aiResponseData map[string]interface{}
-	json.Unmarshal([]byte(simulatedAIResponseStr), &aiResponseData)
-
-	diagnosis := int(aiResponseData["diagnosis"].(float64))
-	finalScore := aiResponseData["probabilitas"].(map[string]interface{})[fmt.Sprintf("%.0f", float64(diagnosis))].(float64)
+	result, rawResponse, err := h.Scorer.Score(c.Request.Context(), aiRequest)
+	if err != nil {
+		// Hasil koreksi sudah tersimpan; klien bisa mengirim ulang untuk mencoba scoring lagi
+		log.Printf("ERROR: Scoring failed for test %d: %v", test.TestID, err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":              "Scoring service is unavailable, please submit again later",
+			"correction_results": allCorrectionResults,
+		})
+		return
+	}
 
-	aiScore := models.AiScore{
-		TestID:                test.TestID,
-		Diagnosis:             diagnosis,
-		FinalDyscalculiaScore: finalScore,
-		RawResponse:           []byte(simulatedAIResponseStr),
+	if err := saveAiScore(h.DB, test.TestID, result, rawResponse); err != nil {
+		log.Printf("ERROR: Could not save AI score for test %d: %v", test.TestID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save test score"})
+		return
 	}
-	h.DB.Create(&aiScore)
 
 	// 9. Kirim hasil lengkap ke frontend
 	c.JSON(http.StatusOK, gin.H{
 		"message":            "Test submitted and graded successfully",
 		"correction_results": allCorrectionResults,
-		"ai_results":         aiResponseData,
+		"ai_results":         result,
 	})
 }
+
+// saveAiScore menyimpan hasil scorer untuk tes. Penilaian ulang menimpa skor sebelumnya untuk tes yang sama.
+func saveAiScore(db *gorm.DB, testID uint, result *scoring.Result, rawResponse []byte) error {
+	aiScore := models.AiScore{
+		TestID:                testID,
+		Diagnosis:             result.Diagnosis,
+		FinalDyscalculiaScore: result.FinalScore(),
+		ModelVersion:          result.ModelVersion,
+		RawResponse:           rawResponse,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "test_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"diagnosis", "final_dyscalculia_score", "model_version", "raw_response", "updated_at"}),
+	}).Create(&aiScore).Error
+}
+
+// TestHistoryHandler mengembalikan riwayat tes akun, atau riwayat satu profil anak dengan ?profile_id=
 func (h *Handler) TestHistoryHandler(c *gin.Context) {
 	userIDClaim, _ := c.Get("user_id")
 	userID := uint(userIDClaim.(float64))
 
+	query := h.DB.Preload("AiScore").Where("user_id = ?", userID)
+	if profileIDStr := c.Query("profile_id"); profileIDStr != "" {
+		profileID, err := strconv.ParseUint(profileIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile_id"})
+			return
+		}
+		if _, err := h.findOwnedProfile(userID, uint(profileID)); err != nil {
+			h.respondProfileError(c, err)
+			return
+		}
+		query = query.Where("profile_id = ?", profileID)
+	} else {
+		// Tanpa profile_id hanya tes milik akun itu sendiri, tidak tercampur dengan tes profil anak
+		query = query.Where("profile_id IS NULL")
+	}
+
 	var tests []models.UserTest
-	err := h.DB.Preload("AiScore").Where("user_id = ?", userID).Order("created_at desc").Find(&tests).Error
+	err := query.Order("created_at desc").Find(&tests).Error
 	if err != nil {
 		log.Printf("ERROR: Could not fetch test history: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test history"})
@@ -483,6 +564,7 @@ func (h *Handler) UserProfileHandler(c *gin.Context) {
 		"name":        user.Name,
 		"email":       user.Email,
 		"picture_url": user.PictureURL,
+		"role":        user.Role,
 		"joined_at":   user.CreatedAt,
 	})
 }
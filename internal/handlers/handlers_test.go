@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"Dysec/internal/auth"
+	"Dysec/internal/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Penolakan token terjadi sebelum database disentuh, jadi Handler tanpa DB cukup untuk test ini
+func TestGoogleAuthHandlerRejectsTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	static, err := auth.NewStaticKeyVerifier("test-secret", "")
+	if err != nil {
+		t.Fatalf("NewStaticKeyVerifier: %v", err)
+	}
+	h := Handler{Verifier: auth.WithPolicy(static, auth.Policy{
+		AllowedClientIDs:     []string{"client-1"},
+		RequireEmailVerified: true,
+	})}
+	mint := func(id auth.Identity, ttl time.Duration) string {
+		token, err := static.Mint(id, ttl)
+		if err != nil {
+			t.Fatalf("Mint: %v", err)
+		}
+		return `{"token":"` + token + `"}`
+	}
+	valid := auth.Identity{Subject: "user-1", Audience: "client-1", EmailVerified: true}
+	unverified := valid
+	unverified.EmailVerified = false
+	otherApp := valid
+	otherApp.Audience = "client-2"
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"missing token", `{}`, http.StatusBadRequest, ""},
+		{"malformed body", `{"token":`, http.StatusBadRequest, ""},
+		{"garbage token", `{"token":"abc"}`, http.StatusUnauthorized, "invalid_token"},
+		{"expired", mint(valid, -time.Minute), http.StatusUnauthorized, "token_expired"},
+		{"unverified email", mint(unverified, time.Minute), http.StatusUnauthorized, "email_not_verified"},
+		{"other application", mint(otherApp, time.Minute), http.StatusUnauthorized, "invalid_audience"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/google", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			h.GoogleAuthHandler(c)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			var body map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if tt.code != "" && body["code"] != tt.code {
+				t.Errorf("code = %v, want %s", body["code"], tt.code)
+			}
+		})
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	key := []byte("audit-key")
+	digest := emailDigest(key, "User@Example.com")
+	tests := []struct {
+		name  string
+		key   []byte
+		email string
+		same  bool
+	}{
+		{"same email, other case and spaces", key, "  user@example.com ", true},
+		{"other email", key, "other@example.com", false},
+		{"other key", []byte("other-key"), "User@Example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emailDigest(tt.key, tt.email); (got == digest) != tt.same {
+				t.Errorf("emailDigest(%q) = %s, digest of User@Example.com = %s, want equal %v", tt.email, got, digest, tt.same)
+			}
+		})
+	}
+	if got := emailDigest(nil, "user@example.com"); got != "" {
+		t.Errorf("emailDigest without key = %q, want empty", got)
+	}
+	if digest == auth.HashToken("user@example.com") {
+		t.Error("emailDigest is the unkeyed SHA-256 of the email")
+	}
+}
+
+func TestSubtestPerformance(t *testing.T) {
+	rows := []models.ItemResponse{
+		{SubtestName: "dot", Correct: true, ResponseTimeMs: 900},
+		{SubtestName: "dot", Correct: false, ResponseTimeMs: 1300},
+		{SubtestName: "dot", Correct: true, ResponseTimeMs: 1100},
+		{SubtestName: "dot", Correct: true},
+		{SubtestName: "stroop", Correct: true, ResponseTimeMs: 700},
+		{SubtestName: "stroop", Correct: false, ResponseTimeMs: 500},
+	}
+	tests := []struct {
+		subtest string
+		rt, acc float64
+	}{
+		{"dot", 1100, 0.75},
+		{"stroop", 600, 0.5},
+		{"addition", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subtest, func(t *testing.T) {
+			rt, acc := subtestPerformance(rows, tt.subtest)
+			if rt != tt.rt || acc != tt.acc {
+				t.Errorf("subtestPerformance = (%v, %v), want (%v, %v)", rt, acc, tt.rt, tt.acc)
+			}
+		})
+	}
+}
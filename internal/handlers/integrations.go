@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxIntegrationPageSize = 500
+
+// organizationTests membatasi query ke tes yang dikerjakan dalam organisasi API key. Halaman
+// berikutnya diambil dengan cursor ?since=RFC3339&after_id= dari next_cursor respons sebelumnya;
+// cursorColumn adalah kolom waktu yang difilter, diurutkan, dan dikembalikan ke klien, dengan
+// test_id sebagai pemecah urutan untuk baris yang waktunya sama. Limit lewat ?limit=.
+func organizationTests(c *gin.Context, db *gorm.DB, cursorColumn string) (*gorm.DB, bool) {
+	organizationIDClaim, _ := c.Get("organization_id")
+	organizationID := uint(organizationIDClaim.(float64))
+
+	query := db.Model(&models.UserTest{}).Where("user_tests.organization_id = ?", organizationID)
+
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC3339 timestamp"})
+			return nil, false
+		}
+		afterID := uint64(0)
+		if a := c.Query("after_id"); a != "" {
+			afterID, err = strconv.ParseUint(a, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "after_id must be a test id"})
+				return nil, false
+			}
+		}
+		query = query.Where("("+cursorColumn+" > ? OR ("+cursorColumn+" = ? AND user_tests.test_id > ?))", t, t, afterID)
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxIntegrationPageSize)
+	}
+	return query.Order(cursorColumn + " asc, user_tests.test_id asc").Limit(limit), true
+}
+
+// nextCursor adalah parameter since dan after_id untuk halaman berikutnya, nil jika halaman kosong
+func nextCursor(count int, last func() (time.Time, uint)) gin.H {
+	if count == 0 {
+		return nil
+	}
+	at, testID := last()
+	return gin.H{"since": at.Format(time.RFC3339Nano), "after_id": testID}
+}
+
+func (h *Handler) IntegrationTestsHandler(c *gin.Context) {
+	query, ok := organizationTests(c, h.DB, "user_tests.created_at")
+	if !ok {
+		return
+	}
+
+	type testRow struct {
+		TestID            uint            `json:"test_id"`
+		UserID            uint            `json:"user_id"`
+		ProfileID         *uint           `json:"profile_id"`
+		CorrectionResults json.RawMessage `json:"correction_results"`
+		CreatedAt         time.Time       `json:"created_at"`
+	}
+	var rows []testRow
+	err := query.Select("user_tests.test_id, user_tests.user_id, user_tests.profile_id, user_tests.correction_results, user_tests.created_at").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("ERROR: Could not fetch integration tests: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tests": rows,
+		"next_cursor": nextCursor(len(rows), func() (time.Time, uint) {
+			return rows[len(rows)-1].CreatedAt, rows[len(rows)-1].TestID
+		}),
+	})
+}
+
+func (h *Handler) IntegrationResultsHandler(c *gin.Context) {
+	// Skor bisa ditulis ulang setelah tes dibuat (submit ulang setelah scoring gagal), jadi cursor
+	// memakai ai_scores.updated_at agar skor baru atau yang berubah tetap terkirim
+	query, ok := organizationTests(c, h.DB, "ai_scores.updated_at")
+	if !ok {
+		return
+	}
+
+	type resultRow struct {
+		TestID                uint      `json:"test_id"`
+		UserID                uint      `json:"user_id"`
+		ProfileID             *uint     `json:"profile_id"`
+		Diagnosis             int       `json:"diagnosis"`
+		FinalDyscalculiaScore float64   `json:"final_dyscalculia_score"`
+		UpdatedAt             time.Time `json:"updated_at"`
+	}
+	var rows []resultRow
+	err := query.Joins("JOIN ai_scores ON ai_scores.test_id = user_tests.test_id").
+		Select("user_tests.test_id, user_tests.user_id, user_tests.profile_id, ai_scores.diagnosis, ai_scores.final_dyscalculia_score, ai_scores.updated_at").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("ERROR: Could not fetch integration results: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch results"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": rows,
+		"next_cursor": nextCursor(len(rows), func() (time.Time, uint) {
+			return rows[len(rows)-1].UpdatedAt, rows[len(rows)-1].TestID
+		}),
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListItemStatsHandler menampilkan hasil analisis soal terakhir. Filter: subtest, flag
+// (mis. too_easy), atau flagged=true untuk semua soal yang ditandai.
+func (h *Handler) ListItemStatsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultQuestionPageSize)))
+	if pageSize < 1 || pageSize > maxQuestionPageSize {
+		pageSize = defaultQuestionPageSize
+	}
+
+	query := h.DB.Model(&models.ItemStatistic{})
+	if subtest := c.Query("subtest"); subtest != "" {
+		query = query.Where("subtest_name = ?", subtest)
+	}
+	if flag := c.Query("flag"); flag != "" {
+		query = query.Where("' ' || flags || ' ' LIKE ?", "% "+flag+" %")
+	}
+	if flagged, _ := strconv.ParseBool(c.Query("flagged")); flagged {
+		query = query.Where("flags <> ''")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("ERROR: Could not count item statistics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch item statistics"})
+		return
+	}
+
+	var stats []models.ItemStatistic
+	if err := query.Order("question_id asc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&stats).Error; err != nil {
+		log.Printf("ERROR: Could not fetch item statistics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch item statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statistics": stats,
+		"thresholds": h.ItemStats.Thresholds,
+		"page":       page,
+		"page_size":  pageSize,
+		"total":      total,
+	})
+}
+
+// RefreshItemStatsHandler menjalankan analisis soal sekarang tanpa menunggu jadwal berikutnya
+func (h *Handler) RefreshItemStatsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	n, err := h.ItemStats.RunOnce()
+	if err != nil {
+		log.Printf("ERROR: Item analysis failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute item statistics"})
+		return
+	}
+	audit(h.DB, c, userID, "question.stats_refreshed", "question", "", gin.H{"questions": n})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item statistics updated successfully", "questions": n})
+}
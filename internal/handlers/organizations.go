@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"Dysec/internal/auth"
+	"Dysec/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ListOrganizationsHandler(c *gin.Context) {
+	var organizations []models.Organization
+	if err := h.DB.Order("id asc").Find(&organizations).Error; err != nil {
+		log.Printf("ERROR: Could not fetch organizations: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"organizations": organizations})
+}
+
+func (h *Handler) CreateOrganizationHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: name is required"})
+		return
+	}
+
+	organization := models.Organization{Name: req.Name}
+	if err := h.DB.Create(&organization).Error; err != nil {
+		log.Printf("ERROR: Could not create organization: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		return
+	}
+	audit(h.DB, c, userID, "organization.created", "organization", organization.ID, gin.H{"name": organization.Name})
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Organization created successfully", "organization": organization})
+}
+
+func (h *Handler) UpdateUserOrganizationHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	targetID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var req UserOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body structure"})
+		return
+	}
+	if req.OrganizationID != nil {
+		if err := h.DB.First(&models.Organization{}, *req.OrganizationID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+			return
+		}
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, targetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err := h.DB.Model(&user).Update("organization_id", req.OrganizationID).Error; err != nil {
+		log.Printf("ERROR: Could not update organization for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update organization"})
+		return
+	}
+	audit(h.DB, c, uint(userIDClaim.(float64)), "user.organization_changed", "user", user.ID, gin.H{"organization_id": req.OrganizationID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Organization updated successfully", "user": user})
+}
+
+func (h *Handler) ListAPIKeysHandler(c *gin.Context) {
+	organizationID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var keys []models.APIKey
+	if err := h.DB.Where("organization_id = ?", organizationID).Order("id asc").Find(&keys).Error; err != nil {
+		log.Printf("ERROR: Could not fetch api keys: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+func (h *Handler) CreateAPIKeyHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	organizationID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: name and scopes are required"})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !isValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope: " + scope, "allowed_scopes": models.APIKeyScopes})
+			return
+		}
+	}
+
+	var organization models.Organization
+	if err := h.DB.First(&organization, organizationID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+	apiKey := models.APIKey{
+		OrganizationID: organization.ID,
+		Name:           req.Name,
+		Prefix:         prefix,
+		KeyHash:        hash,
+		Scopes:         strings.Join(req.Scopes, " "),
+		CreatedBy:      userID,
+	}
+	if err := h.DB.Create(&apiKey).Error; err != nil {
+		log.Printf("ERROR: Could not create api key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+	audit(h.DB, c, userID, "api_key.created", "api_key", apiKey.ID, gin.H{"organization_id": organization.ID, "scopes": req.Scopes})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created. Store it now, it will not be shown again.",
+		"key":     key,
+		"api_key": apiKey,
+	})
+}
+
+func (h *Handler) RevokeAPIKeyHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	keyID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	result := h.DB.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", keyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Printf("ERROR: Could not revoke api key %d: %v", keyID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found or already revoked"})
+		return
+	}
+	audit(h.DB, c, uint(userIDClaim.(float64)), "api_key.revoked", "api_key", keyID, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+func isValidScope(scope string) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errProfileNotOwned = errors.New("profile not found or not owned by user")
+
+// findOwnedProfile memastikan profil anak milik guardian yang sedang login
+func (h *Handler) findOwnedProfile(guardianID, profileID uint) (*models.ChildProfile, error) {
+	var profile models.ChildProfile
+	err := h.DB.Where("id = ? AND guardian_id = ?", profileID, guardianID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errProfileNotOwned
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (h *Handler) respondProfileError(c *gin.Context, err error) {
+	if errors.Is(err, errProfileNotOwned) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found or you do not have permission"})
+		return
+	}
+	log.Printf("ERROR: Could not fetch child profile: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+}
+
+func parseProfileRequest(req ChildProfileRequest) (time.Time, bool) {
+	birthDate, err := time.Parse("2006-01-02", req.BirthDate)
+	if err != nil || birthDate.After(time.Now()) {
+		return time.Time{}, false
+	}
+	return birthDate, true
+}
+
+func (h *Handler) ListProfilesHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	var profiles []models.ChildProfile
+	if err := h.DB.Where("guardian_id = ?", userID).Order("created_at asc").Find(&profiles).Error; err != nil {
+		log.Printf("ERROR: Could not fetch child profiles: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profiles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+func (h *Handler) CreateProfileHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	var req ChildProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: name and birth_date are required"})
+		return
+	}
+	birthDate, ok := parseProfileRequest(req)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "birth_date must be a past date in YYYY-MM-DD format"})
+		return
+	}
+
+	profile := models.ChildProfile{
+		GuardianID: userID,
+		Name:       req.Name,
+		BirthDate:  birthDate,
+		Grade:      req.Grade,
+	}
+	if err := h.DB.Create(&profile).Error; err != nil {
+		log.Printf("ERROR: Could not create child profile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create profile"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Profile created successfully", "profile": profile})
+}
+
+func (h *Handler) GetProfileHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	profileID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	profile, err := h.findOwnedProfile(userID, uint(profileID))
+	if err != nil {
+		h.respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (h *Handler) UpdateProfileHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	profileID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	profile, err := h.findOwnedProfile(userID, uint(profileID))
+	if err != nil {
+		h.respondProfileError(c, err)
+		return
+	}
+
+	var req ChildProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: name and birth_date are required"})
+		return
+	}
+	birthDate, ok := parseProfileRequest(req)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "birth_date must be a past date in YYYY-MM-DD format"})
+		return
+	}
+
+	profile.Name = req.Name
+	profile.BirthDate = birthDate
+	profile.Grade = req.Grade
+	if err := h.DB.Save(profile).Error; err != nil {
+		log.Printf("ERROR: Could not update child profile %d: %v", profile.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "profile": profile})
+}
+
+// DeleteProfileHandler menghapus profil anak beserta seluruh riwayat tes dan skornya
+func (h *Handler) DeleteProfileHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	profileID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	profile, err := h.findOwnedProfile(userID, uint(profileID))
+	if err != nil {
+		h.respondProfileError(c, err)
+		return
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		testIDs := tx.Model(&models.UserTest{}).Select("test_id").Where("profile_id = ?", profile.ID)
+		err := tx.Model(&models.AIUsage{}).Where("test_id IN (?)", testIDs).Update("test_id", nil).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("test_id IN (?)", testIDs).Delete(&models.AiScore{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("test_id IN (?)", testIDs).Delete(&models.ItemResponse{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("profile_id = ?", profile.ID).Delete(&models.UserTest{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(profile).Error
+	})
+	if err != nil {
+		log.Printf("ERROR: Could not delete child profile %d: %v", profile.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile and its test history deleted successfully"})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"Dysec/internal/prompts"
+	"Dysec/internal/testset"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPromptVersionsHandler menampilkan versi template yang sedang dipakai dan riwayat versi di database
+func (h *Handler) ListPromptVersionsHandler(c *gin.Context) {
+	templateID := c.Param("template_id")
+
+	active, err := h.Prompts.Store.Latest(templateID)
+	if err != nil && !errors.Is(err, prompts.ErrNotFound) {
+		log.Printf("ERROR: Could not load prompt template %s: %v", templateID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load prompt template"})
+		return
+	}
+
+	versions := []models.PromptTemplate{}
+	if store, ok := h.Prompts.Store.(*prompts.DBStore); ok {
+		if versions, err = store.Versions(templateID); err != nil {
+			log.Printf("ERROR: Could not fetch prompt versions for %s: %v", templateID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt versions"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"active": active, "versions": versions})
+}
+
+// CreatePromptVersionHandler menyimpan versi baru template. Hanya tersedia jika prompts.store = db.
+func (h *Handler) CreatePromptVersionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	templateID := c.Param("template_id")
+
+	store, ok := h.Prompts.Store.(*prompts.DBStore)
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prompt store is read-only; set prompts.store to db to edit templates"})
+		return
+	}
+
+	var req PromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: body is required"})
+		return
+	}
+
+	// Coba render dengan variabel contoh agar template rusak tidak pernah menjadi versi aktif
+	trial := prompts.Template{ID: templateID, Body: req.Body}
+	if _, err := trial.Execute(prompts.NewVars(testset.DefaultCounts, models.DifficultyMedium, "8-9", h.Prompts.Locale)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template", "details": err.Error()})
+		return
+	}
+
+	row, err := store.Create(templateID, req.Body, userID)
+	if errors.Is(err, prompts.ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prompt version numbers in the database and template files conflict", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Could not create prompt version for %s: %v", templateID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prompt version"})
+		return
+	}
+	audit(h.DB, c, userID, "prompt.version_created", "prompt_template", row.ID, gin.H{"template_id": templateID, "version": row.Version})
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Prompt version created successfully", "prompt": row})
+}
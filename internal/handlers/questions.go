@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"Dysec/internal/questionbank"
+	"Dysec/internal/testset"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultQuestionPageSize = 20
+	maxQuestionPageSize     = 100
+)
+
+// ListQuestionsHandler menampilkan bank soal dengan filter subtest, status, dan difficulty serta paginasi
+func (h *Handler) ListQuestionsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultQuestionPageSize)))
+	if pageSize < 1 || pageSize > maxQuestionPageSize {
+		pageSize = defaultQuestionPageSize
+	}
+
+	query := questionFilter(c).Scope(h.DB.Model(&models.Question{}))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("ERROR: Could not count questions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
+		return
+	}
+
+	var questions []models.Question
+	if err := query.Order("id asc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&questions).Error; err != nil {
+		log.Printf("ERROR: Could not fetch questions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"questions": questions,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+}
+
+func questionFilter(c *gin.Context) questionbank.Filter {
+	return questionbank.Filter{Subtest: c.Query("subtest"), Status: c.Query("status"), Difficulty: c.Query("difficulty")}
+}
+
+// findQuestion memuat soal berdasarkan ID numerik di path dan menulis respons error jika gagal
+func (h *Handler) findQuestion(c *gin.Context) (*models.Question, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question id"})
+		return nil, false
+	}
+
+	var question models.Question
+	if err := h.DB.First(&question, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+			return nil, false
+		}
+		log.Printf("ERROR: Could not fetch question %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question"})
+		return nil, false
+	}
+	return &question, true
+}
+
+// GetQuestionHandler menampilkan satu soal beserta riwayat versi dan statistiknya
+func (h *Handler) GetQuestionHandler(c *gin.Context) {
+	question, ok := h.findQuestion(c)
+	if !ok {
+		return
+	}
+
+	var versions []models.QuestionVersion
+	if err := h.DB.Where("question_ref_id = ?", question.ID).Order("version desc").Find(&versions).Error; err != nil {
+		log.Printf("ERROR: Could not fetch versions of question %d: %v", question.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question"})
+		return
+	}
+
+	// Statistik bisa belum ada jika soal belum pernah dijawab atau analisis belum berjalan
+	var stats []models.ItemStatistic
+	if err := h.DB.Where("question_id = ?", question.QuestionID).Limit(1).Find(&stats).Error; err != nil {
+		log.Printf("ERROR: Could not fetch statistics of question %d: %v", question.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question"})
+		return
+	}
+	var statistics *models.ItemStatistic
+	if len(stats) > 0 {
+		statistics = &stats[0]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"question": question, "versions": versions, "statistics": statistics})
+}
+
+func respondQuestionError(c *gin.Context, err error, action string) {
+	var verr *testset.ValidationError
+	if errors.As(err, &verr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question", "details": verr.Problems})
+		return
+	}
+	log.Printf("ERROR: Could not %s question: %v", action, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " question"})
+}
+
+// CreateQuestionHandler menambahkan soal ke bank. question_id dibuat otomatis jika kosong.
+func (h *Handler) CreateQuestionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	var req QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: subtest_name, question_data and answer are required"})
+		return
+	}
+
+	question := models.Question{QuestionID: req.QuestionID, Version: 1}
+	if question.QuestionID == "" {
+		question.QuestionID = questionbank.NewQuestionID()
+	}
+	if err := req.record().Apply(&question); err != nil {
+		respondQuestionError(c, err, "create")
+		return
+	}
+	if !h.checkQuestionDuplicate(c, &question, "create") {
+		return
+	}
+
+	var existing int64
+	if err := h.DB.Model(&models.Question{}).Where("question_id = ?", question.QuestionID).Count(&existing).Error; err != nil {
+		respondQuestionError(c, err, "create")
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A question with this question_id already exists"})
+		return
+	}
+
+	if err := h.DB.Create(&question).Error; err != nil {
+		respondQuestionError(c, err, "create")
+		return
+	}
+	audit(h.DB, c, userID, "question.created", "question", question.ID, gin.H{"question_id": question.QuestionID, "subtest": question.SubtestName})
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Question created successfully", "question": question})
+}
+
+// UpdateQuestionHandler mengganti isi soal. Isi sebelumnya disimpan sebagai QuestionVersion agar
+// tes lama yang memakai soal ini tetap bisa ditelusuri. question_id tidak bisa diubah.
+func (h *Handler) UpdateQuestionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	question, ok := h.findQuestion(c)
+	if !ok {
+		return
+	}
+
+	var req QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: subtest_name, question_data and answer are required"})
+		return
+	}
+	if req.QuestionID != "" && req.QuestionID != question.QuestionID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "question_id cannot be changed"})
+		return
+	}
+
+	previous := *question
+	if err := req.record().Apply(question); err != nil {
+		respondQuestionError(c, err, "update")
+		return
+	}
+	if !h.checkQuestionDuplicate(c, question, "update") {
+		return
+	}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		return questionbank.Update(tx, previous, question, userID)
+	})
+	if err != nil {
+		if errors.Is(err, questionbank.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Question was modified concurrently, reload and try again"})
+			return
+		}
+		respondQuestionError(c, err, "update")
+		return
+	}
+	audit(h.DB, c, userID, "question.updated", "question", question.ID, gin.H{"question_id": question.QuestionID, "from_version": previous.Version, "to_version": question.Version})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Question updated successfully", "question": question})
+}
+
+// checkQuestionDuplicate menolak soal aktif yang isinya sama dengan soal aktif lain di bank
+func (h *Handler) checkQuestionDuplicate(c *gin.Context, question *models.Question, action string) bool {
+	dup, err := questionbank.FindDuplicate(h.DB, question)
+	if err != nil {
+		respondQuestionError(c, err, action)
+		return false
+	}
+	if dup != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "An active question with the same content already exists", "duplicate_of": dup.QuestionID})
+		return false
+	}
+	return true
+}
+
+func (req QuestionRequest) record() questionbank.Record {
+	return questionbank.Record{
+		QuestionID:        req.QuestionID,
+		SubtestName:       req.SubtestName,
+		QuestionData:      req.QuestionData,
+		Answer:            req.Answer,
+		Difficulty:        req.Difficulty,
+		Status:            req.Status,
+		IRTDiscrimination: req.IRTDiscrimination,
+		IRTDifficulty:     req.IRTDifficulty,
+	}
+}
+
+// RetireQuestionHandler menonaktifkan soal. Soal tidak dihapus karena masih dirujuk oleh tes lama.
+func (h *Handler) RetireQuestionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	question, ok := h.findQuestion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Model(question).Update("status", models.QuestionStatusRetired).Error; err != nil {
+		log.Printf("ERROR: Could not retire question %d: %v", question.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retire question"})
+		return
+	}
+	audit(h.DB, c, userID, "question.retired", "question", question.ID, gin.H{"question_id": question.QuestionID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Question retired successfully", "question": question})
+}
+
+// BulkQuestionStatusHandler mengubah status banyak soal sekaligus (retire atau activate)
+func (h *Handler) BulkQuestionStatusHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	var req QuestionStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !models.IsValidQuestionStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: ids and a valid status are required", "allowed_statuses": models.QuestionStatuses})
+		return
+	}
+	if len(req.IDs) > maxQuestionPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d ids can be updated at once", maxQuestionPageSize)})
+		return
+	}
+
+	result := h.DB.Model(&models.Question{}).Where("id IN ?", req.IDs).Update("status", req.Status)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Some questions have the same content as an active question and cannot be activated"})
+		return
+	}
+	if result.Error != nil {
+		log.Printf("ERROR: Could not update question status: %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update question status"})
+		return
+	}
+	audit(h.DB, c, userID, "question.bulk_status", "question", "", gin.H{"ids": req.IDs, "status": req.Status, "updated": result.RowsAffected})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Question status updated successfully", "updated": result.RowsAffected})
+}
+
+// PreviewQuestionHandler menampilkan soal dalam format subtests yang diterima klien saat tes dimulai
+func (h *Handler) PreviewQuestionHandler(c *gin.Context) {
+	question, ok := h.findQuestion(c)
+	if !ok {
+		return
+	}
+
+	// Periksa ulang soal yang tersimpan, karena data lama mungkin ditulis sebelum ada validasi
+	var problems []string
+	var verr *testset.ValidationError
+	if _, err := testset.ParseItem(question.SubtestName, question.QuestionID, question.QuestionData, question.AnswerData); errors.As(err, &verr) {
+		problems = verr.Problems
+	} else if err != nil {
+		respondQuestionError(c, err, "preview")
+		return
+	}
+
+	set := testset.NewTestSet()
+	set.Add(question.SubtestName, question.QuestionID, question.QuestionData, question.AnswerData)
+
+	c.JSON(http.StatusOK, gin.H{
+		"question_id": question.QuestionID,
+		"version":     question.Version,
+		"status":      question.Status,
+		"subtests":    gin.H{question.SubtestName: set[question.SubtestName]},
+		"problems":    problems,
+	})
+}
+
+// maxQuestionImportBytes membatasi ukuran file import lewat API; file yang lebih besar memakai CLI
+const maxQuestionImportBytes = 10 << 20
+
+// ImportQuestionsHandler mengimpor soal dari body request (CSV atau JSON, lihat ?format=).
+// Dengan ?dry_run=true hanya laporan yang dikembalikan. Import ditolak seluruhnya jika ada baris
+// yang tidak valid, dengan daftar error per baris.
+func (h *Handler) ImportQuestionsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+
+	format := c.DefaultQuery("format", questionbank.FormatCSV)
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	rows, err := questionbank.Read(http.MaxBytesReader(c.Writer, c.Request.Body, maxQuestionImportBytes), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import file", "details": err.Error()})
+		return
+	}
+
+	report, err := questionbank.Import(h.DB, rows, dryRun, userID)
+	if err != nil {
+		log.Printf("ERROR: Could not import questions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import questions"})
+		return
+	}
+
+	if len(report.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Import contains invalid rows; nothing was saved", "report": report})
+		return
+	}
+	if !dryRun {
+		audit(h.DB, c, userID, "question.imported", "question", "", gin.H{
+			"format": format, "total": report.Total, "created": report.Created, "updated": report.Updated,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// ExportQuestionsHandler mengunduh bank soal (CSV atau JSON) dengan filter yang sama seperti daftar soal
+func (h *Handler) ExportQuestionsHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", questionbank.FormatCSV)
+	if format != questionbank.FormatCSV && format != questionbank.FormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": questionbank.ErrInvalidFormat.Error()})
+		return
+	}
+
+	records, err := questionbank.Export(h.DB, questionFilter(c))
+	if err != nil {
+		log.Printf("ERROR: Could not export questions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export questions"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := questionbank.Write(&buf, format, records); err != nil {
+		log.Printf("ERROR: Could not encode question export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export questions"})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == questionbank.FormatJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+	filename := fmt.Sprintf("questions-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
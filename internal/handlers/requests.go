@@ -1 +1,72 @@
 package handlers
+
+import "encoding/json"
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type ChildProfileRequest struct {
+	Name      string `json:"name" binding:"required"`
+	BirthDate string `json:"birth_date" binding:"required"` // format YYYY-MM-DD
+	Grade     string `json:"grade"`
+}
+
+type StartTestRequest struct {
+	ProfileID *uint `json:"profile_id"`
+	// Age atau Grade menentukan kelompok usia jika tes tidak dikerjakan oleh profil anak
+	Age   *int   `json:"age"`
+	Grade string `json:"grade"`
+	// Seed membuat seluruh tes dari generator prosedural; seed yang sama menghasilkan tes yang sama
+	Seed *int64 `json:"seed"`
+	// Mode: fixed (default) atau adaptive
+	Mode string `json:"mode"`
+}
+
+type AdaptiveResponseRequest struct {
+	QuestionID     string `json:"question_id" binding:"required"`
+	Answer         string `json:"answer"`
+	ResponseTimeMs int    `json:"response_time_ms"`
+}
+
+type DeleteAccountRequest struct {
+	ConfirmationToken string `json:"confirmation_token"`
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UserOrganizationRequest struct {
+	OrganizationID *uint `json:"organization_id"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+type PromptTemplateRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type QuestionRequest struct {
+	QuestionID   string          `json:"question_id"`
+	SubtestName  string          `json:"subtest_name" binding:"required"`
+	QuestionData json.RawMessage `json:"question_data" binding:"required"`
+	Answer       string          `json:"answer" binding:"required"`
+	Difficulty   string          `json:"difficulty"`
+	Status       string          `json:"status"`
+	// Parameter IRT; jika kosong diturunkan dari difficulty
+	IRTDiscrimination *float64 `json:"irt_discrimination"`
+	IRTDifficulty     *float64 `json:"irt_difficulty"`
+}
+
+type QuestionStatusRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+	Status string `json:"status" binding:"required"`
+}
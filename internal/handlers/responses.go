@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"Dysec/internal/testset"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// answerKeySet adalah isi answer_key tes biasa. answer_key tes lama bisa berisi angka,
+// jadi nilainya dibaca seperti di SubmitTestHandler.
+type answerKeySet map[string]struct {
+	Questions []json.RawMessage      `json:"questions"`
+	AnswerKey map[string]interface{} `json:"answer_key"`
+}
+
+// questionIDs mengembalikan ID soal per subtes sesuai urutan yang dikirim ke klien
+func (set answerKeySet) questionIDs(subtest string) ([]string, error) {
+	var ids []string
+	for _, raw := range set[subtest].Questions {
+		var q struct {
+			QuestionID string `json:"question_id"`
+		}
+		if err := json.Unmarshal(raw, &q); err != nil || q.QuestionID == "" {
+			return nil, fmt.Errorf("question in %s has no question_id", subtest)
+		}
+		ids = append(ids, q.QuestionID)
+	}
+	return ids, nil
+}
+
+// snapshotQuestionVersions mencatat versi soal bank yang ditampilkan saat tes dimulai, agar respons
+// tetap dikaitkan ke versi tersebut walaupun soal diedit sebelum tes dikirim. Soal prosedural tidak ada
+// di bank sehingga tidak dicatat.
+func snapshotQuestionVersions(db *gorm.DB, subtests json.RawMessage) (json.RawMessage, error) {
+	var set answerKeySet
+	if err := json.Unmarshal(subtests, &set); err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, subtest := range testset.ItemSubtests {
+		subtestIDs, err := set.questionIDs(subtest)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, subtestIDs...)
+	}
+
+	versions, err := currentVersions(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(versions)
+}
+
+func currentVersions(db *gorm.DB, ids []string) (map[string]int, error) {
+	versions := map[string]int{}
+	if len(ids) == 0 {
+		return versions, nil
+	}
+	var questions []models.Question
+	if err := db.Select("question_id", "version").Where("question_id IN ?", ids).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	for _, q := range questions {
+		versions[q.QuestionID] = q.Version
+	}
+	return versions, nil
+}
+
+// saveItemResponses menyimpan satu ItemResponse per soal di answer key tes biasa, termasuk soal yang
+// tidak dijawab. Position mengikuti urutan soal yang dikirim ke klien. Pengiriman ulang menimpa respons sebelumnya.
+func saveItemResponses(db *gorm.DB, test *models.UserTest, payloads map[string]SubtestPayload) error {
+	var set answerKeySet
+	if err := json.Unmarshal(test.AnswerKey, &set); err != nil {
+		return err
+	}
+
+	var rows []models.ItemResponse
+	var ids []string
+	for _, subtest := range testset.ItemSubtests {
+		st := set[subtest]
+		payload := payloads[subtest]
+		subtestIDs, err := set.questionIDs(subtest)
+		if err != nil {
+			return err
+		}
+		for _, id := range subtestIDs {
+			answer, answered := payload.Answers[id]
+			correctAnswer, inKey := st.AnswerKey[id]
+			rows = append(rows, models.ItemResponse{
+				TestID:         test.TestID,
+				QuestionID:     id,
+				SubtestName:    subtest,
+				Position:       len(rows) + 1,
+				Answer:         answer,
+				Correct:        answered && inKey && answer == fmt.Sprintf("%v", correctAnswer),
+				ResponseTimeMs: payload.ResponseTimesMs[id],
+			})
+		}
+		ids = append(ids, subtestIDs...)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	// Versi yang dicatat saat tes dimulai; tes lama tanpa catatan memakai versi bank saat ini.
+	// Soal prosedural yang tidak ada di bank tetap versi 1.
+	var versions map[string]int
+	if len(test.QuestionVersions) > 0 {
+		if err := json.Unmarshal(test.QuestionVersions, &versions); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if versions, err = currentVersions(db, ids); err != nil {
+			return err
+		}
+	}
+	for i := range rows {
+		rows[i].QuestionVersion = 1
+		if v, ok := versions[rows[i].QuestionID]; ok {
+			rows[i].QuestionVersion = v
+		}
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "test_id"}, {Name: "question_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"answer", "correct", "response_time_ms", "question_version"}),
+	}).Create(&rows).Error
+}
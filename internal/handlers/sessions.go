@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// startSession membuat session untuk perangkat yang login. Login ulang dari device_id
+// yang sama memakai session lama dan mencabut refresh token sebelumnya.
+func startSession(tx *gorm.DB, c *gin.Context, userID uint, deviceID, deviceName string) (*models.Session, error) {
+	var session models.Session
+	if deviceID != "" {
+		err := tx.Where("user_id = ? AND device_id = ? AND revoked_at IS NULL", userID, deviceID).
+			Order("id desc").First(&session).Error
+		if err == nil {
+			if err := tx.Model(&models.RefreshToken{}).
+				Where("session_id = ? AND revoked_at IS NULL", session.ID).
+				Update("revoked_at", time.Now()).Error; err != nil {
+				return nil, err
+			}
+			session.UserAgent = c.Request.UserAgent()
+			session.IPAddress = c.ClientIP()
+			if deviceName != "" {
+				session.DeviceName = deviceName
+			}
+			return &session, tx.Save(&session).Error
+		}
+	}
+
+	session = models.Session{
+		UserID:     userID,
+		DeviceID:   deviceID,
+		DeviceName: deviceName,
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now(),
+	}
+	return &session, tx.Create(&session).Error
+}
+
+// revokeSessions mencabut session yang cocok dengan kondisi beserta semua refresh token-nya
+func revokeSessions(tx *gorm.DB, query interface{}, args ...interface{}) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&models.Session{}).Where(query, args...).Where("revoked_at IS NULL").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		now := time.Now()
+		if err := tx.Model(&models.Session{}).Where("id IN ?", ids).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("session_id IN ? AND revoked_at IS NULL", ids).
+			Update("revoked_at", now).Error
+	})
+}
+
+func (h *Handler) LogoutHandler(c *gin.Context) {
+	sessionIDClaim, _ := c.Get("session_id")
+	sessionID := uint(sessionIDClaim.(float64))
+
+	if err := revokeSessions(h.DB, "id = ?", sessionID); err != nil {
+		log.Printf("ERROR: Could not revoke session %d: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+func (h *Handler) ListSessionsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	sessionIDClaim, _ := c.Get("session_id")
+	currentSessionID := uint(sessionIDClaim.(float64))
+
+	var sessions []models.Session
+	err := h.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").Find(&sessions).Error
+	if err != nil {
+		log.Printf("ERROR: Could not fetch sessions: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, gin.H{
+			"id":           s.ID,
+			"device_id":    s.DeviceID,
+			"device_name":  s.DeviceName,
+			"user_agent":   s.UserAgent,
+			"ip_address":   s.IPAddress,
+			"last_seen_at": s.LastSeenAt,
+			"created_at":   s.CreatedAt,
+			"current":      s.ID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": result})
+}
+
+func (h *Handler) RevokeSessionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("user_id")
+	userID := uint(userIDClaim.(float64))
+	sessionID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var session models.Session
+	if err := h.DB.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or you do not have permission"})
+		return
+	}
+
+	if err := revokeSessions(h.DB, "id = ?", session.ID); err != nil {
+		log.Printf("ERROR: Could not revoke session %d: %v", session.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"Dysec/internal/auth"
+	"Dysec/internal/models"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// issueTokens membuat access token baru dan menyimpan hash refresh token baru untuk session
+func (h *Handler) issueTokens(tx *gorm.DB, user models.User, session *models.Session) (gin.H, error) {
+	accessToken, expiresAt, err := h.Tokens.GenerateAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := models.RefreshToken{
+		UserID:    user.ID,
+		SessionID: session.ID,
+		TokenHash: refreshHash,
+		ExpiresAt: now.Add(h.Tokens.RefreshTokenTTL),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	// Session tetap hidup selama refresh token terakhirnya masih berlaku
+	session.LastSeenAt = now
+	session.ExpiresAt = record.ExpiresAt
+	if err := tx.Model(session).Updates(map[string]interface{}{
+		"last_seen_at": session.LastSeenAt,
+		"expires_at":   session.ExpiresAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(time.Until(expiresAt).Seconds()),
+		"session_id":    session.ID,
+	}, nil
+}
+
+var errRefreshTokenInvalid = errors.New("refresh token is invalid")
+
+func (h *Handler) RefreshTokenHandler(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: refresh_token is required"})
+		return
+	}
+
+	var tokens gin.H
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Where("token_hash = ?", auth.HashToken(req.RefreshToken)).First(&stored).Error; err != nil {
+			return errRefreshTokenInvalid
+		}
+
+		now := time.Now()
+		if stored.RevokedAt != nil {
+			// Token lama dipakai ulang: kemungkinan dicuri, cabut seluruh session perangkat tersebut
+			log.Printf("WARNING: Reuse of revoked refresh token %d detected for user %d. Revoking session %d.", stored.ID, stored.UserID, stored.SessionID)
+			if err := revokeSessions(h.DB, "id = ?", stored.SessionID); err != nil {
+				log.Printf("ERROR: Could not revoke session %d: %v", stored.SessionID, err)
+			}
+			return errRefreshTokenInvalid
+		}
+		if now.After(stored.ExpiresAt) {
+			return errRefreshTokenInvalid
+		}
+
+		var session models.Session
+		if err := tx.Where("id = ? AND revoked_at IS NULL", stored.SessionID).First(&session).Error; err != nil {
+			return errRefreshTokenInvalid
+		}
+
+		// Rotasi: token yang dipakai langsung dicabut. RowsAffected mencegah dua request paralel sama-sama berhasil.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return errRefreshTokenInvalid
+		}
+
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			return errRefreshTokenInvalid
+		}
+
+		var err error
+		tokens, err = h.issueTokens(tx, user, &session)
+		return err
+	})
+	if errors.Is(err, errRefreshTokenInvalid) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Could not refresh tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not refresh token"})
+		return
+	}
+
+	tokens["message"] = "Token refreshed successfully"
+	c.JSON(http.StatusOK, tokens)
+}
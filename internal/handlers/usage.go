@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"Dysec/internal/models"
+	"Dysec/internal/usage"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withinAIBudget memeriksa budget AI bulanan user. Jika ledger gagal dibaca, generasi tetap diizinkan.
+func (h *Handler) withinAIBudget(c *gin.Context, userID uint) bool {
+	status, err := h.Usage.CheckBudget(userID, c.GetString("role"))
+	if err != nil {
+		log.Printf("ERROR: Could not check AI budget for user %d: %v", userID, err)
+		return true
+	}
+	if status.Exceeded {
+		log.Printf("INFO: User %d exceeded monthly AI budget (%d tokens, $%.4f). Using question bank only.", userID, status.Used.TotalTokens, status.Used.CostUSD)
+	}
+	return !status.Exceeded
+}
+
+// AIUsageHandler mengagregasi pemakaian AI per hari, user, atau model.
+// Query: group_by (day|user|model, default day), from dan to (YYYY-MM-DD, default 30 hari terakhir).
+func (h *Handler) AIUsageHandler(c *gin.Context) {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.Local)
+	from := to.AddDate(0, 0, -30)
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from, use YYYY-MM-DD"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to, use YYYY-MM-DD"})
+			return
+		}
+		// Tanggal to ikut dihitung
+		to = to.AddDate(0, 0, 1)
+	}
+
+	groupBy := c.DefaultQuery("group_by", "day")
+	rows, err := h.Usage.Aggregate(groupBy, from, to)
+	if err != nil {
+		if errors.Is(err, usage.ErrInvalidGroup) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("ERROR: Could not aggregate AI usage: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate AI usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group_by": groupBy,
+		"from":     from.Format("2006-01-02"),
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"usage":    rows,
+	})
+}
+
+// UserAIBudgetHandler menampilkan pemakaian AI bulan berjalan seorang user dibandingkan budget-nya
+func (h *Handler) UserAIBudgetHandler(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, targetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	status, err := h.Usage.CheckBudget(user.ID, user.Role)
+	if err != nil {
+		log.Printf("ERROR: Could not check AI budget for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check AI budget"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": user.ID, "budget": status})
+}
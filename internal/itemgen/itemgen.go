@@ -0,0 +1,225 @@
+package itemgen
+
+import (
+	"Dysec/internal/models"
+	"Dysec/internal/testset"
+	"fmt"
+	"math/rand"
+)
+
+// Range adalah rentang bilangan bulat inklusif
+type Range struct {
+	Min, Max int
+}
+
+func (r Range) pick(rng *rand.Rand) int {
+	return r.Min + rng.Intn(r.Max-r.Min+1)
+}
+
+// Params adalah rentang parameter soal untuk satu tingkat kesulitan
+type Params struct {
+	Dots                Range // jumlah titik pada dot enumeration
+	StroopDigits        Range // angka yang dibandingkan pada numerical Stroop
+	AddOperands         Range
+	MultOperands        Range
+	LegendSize          int // jumlah pasangan simbol-angka pada substitution
+	AdditionTerms       int
+	MultiplicationTerms int
+}
+
+// DefaultParams disesuaikan dengan usia: easy untuk kelas 1-2 SD (6-8 tahun),
+// medium untuk kelas 3-4 SD (8-10 tahun), hard untuk kelas 5 SD ke atas (10 tahun ke atas)
+var DefaultParams = map[string]Params{
+	models.DifficultyEasy: {
+		Dots: Range{1, 9}, StroopDigits: Range{1, 9}, AddOperands: Range{1, 9}, MultOperands: Range{1, 5},
+		LegendSize: 3, AdditionTerms: 2, MultiplicationTerms: 2,
+	},
+	models.DifficultyMedium: {
+		Dots: Range{5, 15}, StroopDigits: Range{1, 9}, AddOperands: Range{5, 50}, MultOperands: Range{2, 9},
+		LegendSize: 5, AdditionTerms: 2, MultiplicationTerms: 2,
+	},
+	models.DifficultyHard: {
+		Dots: Range{10, 25}, StroopDigits: Range{10, 99}, AddOperands: Range{20, 99}, MultOperands: Range{6, 12},
+		LegendSize: 7, AdditionTerms: 3, MultiplicationTerms: 2,
+	},
+}
+
+var symbols = []string{"▲", "●", "■", "★", "◆", "♥", "♣", "✚", "☾"}
+
+// Generator membuat soal secara prosedural. Seed yang sama selalu menghasilkan soal yang sama.
+type Generator struct {
+	Seed       int64
+	Difficulty string
+	params     Params
+	rng        *rand.Rand
+	next       map[string]int
+}
+
+// New membuat generator untuk seed dan tingkat kesulitan. Kesulitan yang tidak dikenal memakai medium.
+func New(seed int64, difficulty string) *Generator {
+	params, ok := DefaultParams[difficulty]
+	if !ok {
+		difficulty = models.DifficultyMedium
+		params = DefaultParams[difficulty]
+	}
+	return &Generator{
+		Seed:       seed,
+		Difficulty: difficulty,
+		params:     params,
+		rng:        rand.New(rand.NewSource(seed)),
+		next:       map[string]int{},
+	}
+}
+
+// Generate membuat satu set soal sesuai counts. Subtes diproses dengan urutan tetap agar deterministik.
+func (g *Generator) Generate(counts map[string]int) *testset.Generated {
+	out := &testset.Generated{
+		Dot:            []testset.DotItem{},
+		Stroop:         []testset.StroopItem{},
+		Addition:       []testset.ArithmeticItem{},
+		Multiplication: []testset.ArithmeticItem{},
+		Substitution:   []testset.SubstitutionItem{},
+	}
+	for _, subtest := range testset.ItemSubtests {
+		for n := 0; n < counts[subtest]; n++ {
+			switch subtest {
+			case testset.SubtestDot:
+				out.Dot = append(out.Dot, g.dot())
+			case testset.SubtestStroop:
+				out.Stroop = append(out.Stroop, g.stroop())
+			case testset.SubtestAddition:
+				out.Addition = append(out.Addition, g.arithmetic(testset.SubtestAddition))
+			case testset.SubtestMultiplication:
+				out.Multiplication = append(out.Multiplication, g.arithmetic(testset.SubtestMultiplication))
+			case testset.SubtestSubstitution:
+				out.Substitution = append(out.Substitution, g.substitution())
+			}
+		}
+	}
+	return out
+}
+
+// Fill melengkapi set sampai setiap subtes berisi counts soal. Mengembalikan jumlah soal yang ditambahkan.
+func (g *Generator) Fill(set testset.TestSet, counts map[string]int) (int, error) {
+	deficits := map[string]int{}
+	for _, subtest := range testset.ItemSubtests {
+		if d := counts[subtest] - set.Count(subtest); d > 0 {
+			deficits[subtest] = d
+		}
+	}
+
+	added := 0
+	items := g.Generate(deficits).Items()
+	for _, subtest := range testset.ItemSubtests {
+		for _, item := range items[subtest] {
+			question, err := testset.PublicJSON(item)
+			if err != nil {
+				return added, err
+			}
+			set.Add(subtest, item.ID(), question, item.AnswerValue())
+			added++
+		}
+	}
+	return added, nil
+}
+
+func (g *Generator) id(subtest string) string {
+	g.next[subtest]++
+	return fmt.Sprintf("gen_%d_%s_%s_%d", g.Seed, g.Difficulty, subtest, g.next[subtest])
+}
+
+func (g *Generator) dot() testset.DotItem {
+	count := g.params.Dots.pick(g.rng)
+	return testset.DotItem{
+		QuestionID: g.id(testset.SubtestDot),
+		Type:       "text_input",
+		Text:       "Berapa jumlah titik?",
+		DotCount:   count,
+		Answer:     fmt.Sprint(count),
+	}
+}
+
+func (g *Generator) stroop() testset.StroopItem {
+	left := g.params.StroopDigits.pick(g.rng)
+	right := g.params.StroopDigits.pick(g.rng)
+	for right == left {
+		right = g.params.StroopDigits.pick(g.rng)
+	}
+
+	// Separuh soal inkongruen: angka yang nilainya lebih besar ditampilkan lebih kecil
+	leftSize, rightSize := 1+g.rng.Intn(3), 1+g.rng.Intn(3)
+	if g.rng.Intn(2) == 0 {
+		big, small := 3, 1
+		if left > right {
+			leftSize, rightSize = small, big
+		} else {
+			leftSize, rightSize = big, small
+		}
+	}
+
+	return testset.StroopItem{
+		QuestionID: g.id(testset.SubtestStroop),
+		Type:       "choice",
+		Text:       "Pilih angka yang nilainya lebih besar",
+		Left:       left,
+		Right:      right,
+		LeftSize:   leftSize,
+		RightSize:  rightSize,
+		Answer:     fmt.Sprint(max(left, right)),
+	}
+}
+
+func (g *Generator) arithmetic(subtest string) testset.ArithmeticItem {
+	operator, symbol, terms, r := "+", "+", g.params.AdditionTerms, g.params.AddOperands
+	if subtest == testset.SubtestMultiplication {
+		operator, symbol, terms, r = "x", "x", g.params.MultiplicationTerms, g.params.MultOperands
+	}
+
+	operands := make([]int, terms)
+	text := "Berapa "
+	result := 0
+	for i := range operands {
+		operands[i] = r.pick(g.rng)
+		if i == 0 {
+			result = operands[i]
+			text += fmt.Sprint(operands[i])
+			continue
+		}
+		if operator == "+" {
+			result += operands[i]
+		} else {
+			result *= operands[i]
+		}
+		text += fmt.Sprintf(" %s %d", symbol, operands[i])
+	}
+
+	return testset.ArithmeticItem{
+		QuestionID: g.id(subtest),
+		Type:       "text_input",
+		Text:       text + "?",
+		Operands:   operands,
+		Operator:   operator,
+		Answer:     fmt.Sprint(result),
+	}
+}
+
+func (g *Generator) substitution() testset.SubstitutionItem {
+	size := min(g.params.LegendSize, len(symbols))
+	pickedSymbols := g.rng.Perm(len(symbols))[:size]
+	digits := g.rng.Perm(9)[:size]
+
+	legend := make([]testset.SymbolDigit, size)
+	for i := range legend {
+		legend[i] = testset.SymbolDigit{Symbol: symbols[pickedSymbols[i]], Digit: digits[i] + 1}
+	}
+	asked := legend[g.rng.Intn(size)]
+
+	return testset.SubstitutionItem{
+		QuestionID: g.id(testset.SubtestSubstitution),
+		Type:       "text_input",
+		Text:       "Berapa angka untuk simbol ini?",
+		Legend:     legend,
+		Symbol:     asked.Symbol,
+		Answer:     fmt.Sprint(asked.Digit),
+	}
+}
@@ -0,0 +1,68 @@
+package itemgen
+
+import (
+	"Dysec/internal/models"
+	"Dysec/internal/testset"
+	"encoding/json"
+	"testing"
+)
+
+func TestSameSeedSameTest(t *testing.T) {
+	tests := []struct {
+		name       string
+		seed       int64
+		difficulty string
+	}{
+		{"easy", 1, models.DifficultyEasy},
+		{"medium", 42, models.DifficultyMedium},
+		{"hard", -7, models.DifficultyHard},
+		{"unknown difficulty", 42, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := fill(t, tt.seed, tt.difficulty)
+			second := fill(t, tt.seed, tt.difficulty)
+			if first != second {
+				t.Fatalf("seed %d produced different tests:\n%s\n%s", tt.seed, first, second)
+			}
+		})
+	}
+}
+
+func TestDifferentSeedDifferentTest(t *testing.T) {
+	if fill(t, 1, models.DifficultyMedium) == fill(t, 2, models.DifficultyMedium) {
+		t.Fatal("seeds 1 and 2 produced the same test")
+	}
+}
+
+func TestGeneratedItemsAreValid(t *testing.T) {
+	items := New(7, models.DifficultyMedium).Generate(testset.DefaultCounts).Items()
+	for _, subtest := range testset.ItemSubtests {
+		if got, want := len(items[subtest]), testset.DefaultCounts[subtest]; got != want {
+			t.Errorf("%s: got %d items, want %d", subtest, got, want)
+		}
+		for _, item := range items[subtest] {
+			data, err := testset.PublicJSON(item)
+			if err != nil {
+				t.Fatalf("%s: %v", item.ID(), err)
+			}
+			if _, err := testset.ParseItem(subtest, item.ID(), data, item.AnswerValue()); err != nil {
+				t.Errorf("%s: %v", item.ID(), err)
+			}
+		}
+	}
+}
+
+// fill membuat tes prosedural penuh seperti StartSessionHandler dan mengembalikan JSON-nya
+func fill(t *testing.T, seed int64, difficulty string) string {
+	t.Helper()
+	set := testset.NewTestSet()
+	if _, err := New(seed, difficulty).Fill(set, testset.DefaultCounts); err != nil {
+		t.Fatalf("Fill: %v", err)
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return string(data)
+}
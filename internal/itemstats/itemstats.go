@@ -0,0 +1,228 @@
+package itemstats
+
+import (
+	"Dysec/internal/models"
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	FlagTooEasy           = "too_easy"
+	FlagTooHard           = "too_hard"
+	FlagLowDiscrimination = "low_discrimination"
+)
+
+// Thresholds menentukan kapan soal ditandai. Soal dengan exposure di bawah MinExposure tidak ditandai
+// karena statistiknya belum stabil.
+type Thresholds struct {
+	MinExposure       int     `json:"min_exposure"`
+	MinPCorrect       float64 `json:"min_p_correct"`
+	MaxPCorrect       float64 `json:"max_p_correct"`
+	MinDiscrimination float64 `json:"min_discrimination"`
+}
+
+// Flags mengembalikan tanda untuk satu statistik soal
+func (t Thresholds) Flags(s models.ItemStatistic) []string {
+	if s.Exposure < t.MinExposure {
+		return nil
+	}
+	var flags []string
+	if s.PCorrect > t.MaxPCorrect {
+		flags = append(flags, FlagTooEasy)
+	}
+	if s.PCorrect < t.MinPCorrect {
+		flags = append(flags, FlagTooHard)
+	}
+	if s.PointBiserial != nil && *s.PointBiserial < t.MinDiscrimination {
+		flags = append(flags, FlagLowDiscrimination)
+	}
+	return flags
+}
+
+// Aggregate adalah statistik mentah satu soal yang dihitung database dengan aggregateQuery
+type Aggregate struct {
+	QuestionID       string
+	QuestionVersion  int
+	SubtestName      string
+	Exposure         int
+	Correct          int
+	Omitted          int
+	MedianResponseMs *float64
+	PointBiserial    *float64
+}
+
+// Distractor adalah jumlah satu jawaban salah untuk satu soal
+type Distractor struct {
+	QuestionID string
+	Answer     string
+	Count      int
+}
+
+// aggregateQuery menghitung statistik per soal di database agar analisis tidak memuat semua
+// item_responses ke memori. Hanya respons untuk soal bank pada versi terbarunya yang dihitung,
+// agar soal yang sudah diperbaiki tidak tetap ditandai karena respons untuk versi lamanya.
+//
+// Point-biserial adalah korelasi benar/salah soal dengan proporsi benar soal lain di tes yang sama
+// (rest score), dan hanya memakai tes biasa: pada tes adaptif setiap anak mendapat soal berbeda
+// sehingga skor mentahnya tidak sebanding. Rest score memakai semua soal tes, termasuk soal yang
+// tidak dihitung statistiknya. corr() bernilai NULL jika datanya kurang atau salah satu variabel konstan.
+const aggregateQuery = `
+WITH fixed_totals AS (
+	SELECT item_responses.test_id, COUNT(*) AS items, COUNT(*) FILTER (WHERE item_responses.correct) AS correct
+	FROM item_responses
+	JOIN user_tests ON user_tests.test_id = item_responses.test_id
+	WHERE user_tests.mode = ?
+	GROUP BY item_responses.test_id
+)
+SELECT r.question_id, r.question_version, MIN(r.subtest_name) AS subtest_name,
+	COUNT(*) AS exposure,
+	COUNT(*) FILTER (WHERE r.correct) AS correct,
+	COUNT(*) FILTER (WHERE NOT r.correct AND TRIM(COALESCE(r.answer, '')) = '') AS omitted,
+	percentile_cont(0.5) WITHIN GROUP (ORDER BY r.response_time_ms) FILTER (WHERE r.response_time_ms > 0) AS median_response_ms,
+	corr(r.correct::int::float8, (t.correct - r.correct::int)::float8 / (t.items - 1)) FILTER (WHERE t.items > 1) AS point_biserial
+FROM item_responses r
+JOIN questions q ON q.question_id = r.question_id AND q.version = r.question_version
+LEFT JOIN fixed_totals t ON t.test_id = r.test_id
+GROUP BY r.question_id, r.question_version`
+
+// distractorQuery menghitung jawaban salah (bukan kosong) per soal, dengan aturan versi yang sama
+const distractorQuery = `
+SELECT r.question_id, TRIM(r.answer) AS answer, COUNT(*) AS count
+FROM item_responses r
+JOIN questions q ON q.question_id = r.question_id AND q.version = r.question_version
+WHERE NOT r.correct AND TRIM(COALESCE(r.answer, '')) <> ''
+GROUP BY r.question_id, TRIM(r.answer)`
+
+// Build menyusun ItemStatistic dari agregat database lalu menandainya menurut thresholds
+func Build(aggregates []Aggregate, distractors []Distractor, thresholds Thresholds, now time.Time) []models.ItemStatistic {
+	byQuestion := map[string]map[string]int{}
+	for _, d := range distractors {
+		if byQuestion[d.QuestionID] == nil {
+			byQuestion[d.QuestionID] = map[string]int{}
+		}
+		byQuestion[d.QuestionID][d.Answer] += d.Count
+	}
+
+	stats := make([]models.ItemStatistic, 0, len(aggregates))
+	for _, a := range aggregates {
+		if a.Exposure == 0 {
+			continue
+		}
+		counts := byQuestion[a.QuestionID]
+		if counts == nil {
+			counts = map[string]int{}
+		}
+		s := models.ItemStatistic{
+			QuestionID:       a.QuestionID,
+			QuestionVersion:  a.QuestionVersion,
+			SubtestName:      a.SubtestName,
+			Exposure:         a.Exposure,
+			PCorrect:         float64(a.Correct) / float64(a.Exposure),
+			PointBiserial:    a.PointBiserial,
+			MedianResponseMs: a.MedianResponseMs,
+			Omitted:          a.Omitted,
+			ComputedAt:       now,
+		}
+		s.Distractors, _ = json.Marshal(counts)
+		s.Flags = strings.Join(thresholds.Flags(s), " ")
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].QuestionID < stats[j].QuestionID })
+	return stats
+}
+
+// Analyzer menghitung ulang ItemStatistic dari ItemResponse secara berkala
+type Analyzer struct {
+	DB         *gorm.DB
+	Thresholds Thresholds
+	Interval   time.Duration
+}
+
+// NewAnalyzerFromConfig membaca item_analysis.*
+func NewAnalyzerFromConfig(db *gorm.DB) *Analyzer {
+	a := &Analyzer{
+		DB: db,
+		Thresholds: Thresholds{
+			MinExposure:       viper.GetInt("item_analysis.min_exposure"),
+			MinPCorrect:       viper.GetFloat64("item_analysis.min_p_correct"),
+			MaxPCorrect:       viper.GetFloat64("item_analysis.max_p_correct"),
+			MinDiscrimination: viper.GetFloat64("item_analysis.min_discrimination"),
+		},
+		Interval: viper.GetDuration("item_analysis.interval"),
+	}
+	if a.Thresholds.MinExposure <= 0 {
+		a.Thresholds.MinExposure = 30
+	}
+	if a.Thresholds.MaxPCorrect <= 0 {
+		a.Thresholds.MaxPCorrect = 0.95
+	}
+	if !viper.IsSet("item_analysis.min_p_correct") {
+		a.Thresholds.MinPCorrect = 0.2
+	}
+	if !viper.IsSet("item_analysis.min_discrimination") {
+		a.Thresholds.MinDiscrimination = 0.2
+	}
+	if a.Interval <= 0 {
+		a.Interval = time.Hour
+	}
+	return a
+}
+
+// Start menjalankan analisis di goroutine terpisah sampai ctx dibatalkan
+func (a *Analyzer) Start(ctx context.Context) {
+	go func() {
+		log.Printf("INFO: Item analysis job started (every %s)", a.Interval)
+		ticker := time.NewTicker(a.Interval)
+		defer ticker.Stop()
+		for {
+			if n, err := a.RunOnce(); err != nil {
+				log.Printf("ERROR: Item analysis failed: %v", err)
+			} else {
+				log.Printf("INFO: Item analysis updated %d questions", n)
+			}
+			select {
+			case <-ctx.Done():
+				log.Println("INFO: Item analysis job stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce menghitung ulang semua statistik di database lalu memperbarui tabel item_statistics dalam
+// satu transaksi. Statistik soal yang tidak lagi punya respons terhitung (mis. baru diedit) dihapus.
+func (a *Analyzer) RunOnce() (int, error) {
+	var aggregates []Aggregate
+	if err := a.DB.Raw(aggregateQuery, models.TestModeFixed).Scan(&aggregates).Error; err != nil {
+		return 0, err
+	}
+	var distractors []Distractor
+	if err := a.DB.Raw(distractorQuery).Scan(&distractors).Error; err != nil {
+		return 0, err
+	}
+
+	// Dibulatkan ke mikrodetik (presisi Postgres) agar baris yang baru ditulis tidak ikut terhapus
+	now := time.Now().Truncate(time.Microsecond)
+	stats := Build(aggregates, distractors, a.Thresholds, now)
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if len(stats) > 0 {
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(stats, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("computed_at < ?", now).Delete(&models.ItemStatistic{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(stats), nil
+}
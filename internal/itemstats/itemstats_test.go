@@ -0,0 +1,232 @@
+package itemstats
+
+import (
+	"Dysec/internal/database"
+	"Dysec/internal/models"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestBuild(t *testing.T) {
+	thresholds := Thresholds{MinExposure: 3, MinPCorrect: 0.2, MaxPCorrect: 0.9, MinDiscrimination: 0.2}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	aggregates := []Aggregate{
+		{QuestionID: "q2", QuestionVersion: 1, SubtestName: "addition", Exposure: 4, Correct: 4},
+		{QuestionID: "q1", QuestionVersion: 2, SubtestName: "addition", Exposure: 4, Correct: 2, MedianResponseMs: ptr(2500), PointBiserial: ptr(0.8)},
+		{QuestionID: "q3", QuestionVersion: 1, SubtestName: "addition", Exposure: 4, Omitted: 1, PointBiserial: ptr(-0.3)},
+		{QuestionID: "q4", QuestionVersion: 1, SubtestName: "addition"},
+	}
+	distractors := []Distractor{
+		{QuestionID: "q1", Answer: "20", Count: 2},
+		{QuestionID: "q3", Answer: "1", Count: 2},
+		{QuestionID: "q3", Answer: "2", Count: 1},
+	}
+
+	stats := Build(aggregates, distractors, thresholds, now)
+	tests := []struct {
+		question    string
+		pCorrect    float64
+		distractors string
+		flags       string
+	}{
+		{"q1", 0.5, `{"20":2}`, ""},
+		{"q2", 1, `{}`, FlagTooEasy},
+		{"q3", 0, `{"1":2,"2":1}`, FlagTooHard + " " + FlagLowDiscrimination},
+	}
+	// Soal tanpa exposure dilewati dan hasilnya urut menurut question_id
+	if len(stats) != len(tests) {
+		t.Fatalf("got %d statistics, want %d: %+v", len(stats), len(tests), stats)
+	}
+	for i, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			s := stats[i]
+			if s.QuestionID != tt.question {
+				t.Fatalf("statistic %d = %s, want %s", i, s.QuestionID, tt.question)
+			}
+			if s.PCorrect != tt.pCorrect {
+				t.Errorf("p-correct = %v, want %v", s.PCorrect, tt.pCorrect)
+			}
+			if string(s.Distractors) != tt.distractors {
+				t.Errorf("distractors = %s, want %s", s.Distractors, tt.distractors)
+			}
+			if s.Flags != tt.flags {
+				t.Errorf("flags = %q, want %q", s.Flags, tt.flags)
+			}
+			if !s.ComputedAt.Equal(now) {
+				t.Errorf("computed_at = %v", s.ComputedAt)
+			}
+		})
+	}
+	if s := stats[0]; s.QuestionVersion != 2 || s.MedianResponseMs == nil || *s.MedianResponseMs != 2500 || s.PointBiserial == nil {
+		t.Errorf("q1 = %+v, want version, median and point-biserial copied from the aggregate", s)
+	}
+	if stats[2].Omitted != 1 {
+		t.Errorf("q3 omitted = %d, want 1", stats[2].Omitted)
+	}
+}
+
+func TestThresholdFlags(t *testing.T) {
+	thresholds := Thresholds{MinExposure: 30, MinPCorrect: 0.2, MaxPCorrect: 0.95, MinDiscrimination: 0.2}
+	tests := []struct {
+		name  string
+		stat  models.ItemStatistic
+		flags []string
+	}{
+		{"too few exposures", models.ItemStatistic{Exposure: 10, PCorrect: 1}, nil},
+		{"good item", models.ItemStatistic{Exposure: 40, PCorrect: 0.6, PointBiserial: ptr(0.4)}, nil},
+		{"too easy", models.ItemStatistic{Exposure: 40, PCorrect: 0.99}, []string{FlagTooEasy}},
+		{"too hard and low discrimination", models.ItemStatistic{Exposure: 40, PCorrect: 0.1, PointBiserial: ptr(-0.1)}, []string{FlagTooHard, FlagLowDiscrimination}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := thresholds.Flags(tt.stat)
+			if len(got) != len(tt.flags) {
+				t.Fatalf("flags = %v, want %v", got, tt.flags)
+			}
+			for i := range got {
+				if got[i] != tt.flags[i] {
+					t.Fatalf("flags = %v, want %v", got, tt.flags)
+				}
+			}
+		})
+	}
+}
+
+// TestRunOnce menjalankan query agregat di Postgres dalam transaksi yang di-rollback, sehingga
+// tidak mengubah data test lain yang memakai database yang sama. Tanpa DB_HOST test dilewati.
+func TestRunOnce(t *testing.T) {
+	if os.Getenv("DB_HOST") == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("DB_HOST is not set; CI must run the item analysis test against its Postgres service")
+		}
+		t.Skip("set DB_HOST, DB_PORT, DB_USER, DB_PASSWORD and DB_NAME to run the item analysis test against Postgres")
+	}
+	viper.Reset()
+	viper.AutomaticEnv()
+	db, err := database.Connect()
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	prefix := fmt.Sprintf("itemstats_%d_", time.Now().UnixNano())
+	id := func(q string) string { return prefix + q }
+	user := models.User{GoogleID: prefix, Email: prefix + "@example.com", Name: "Item Stats"}
+	must(t, tx.Create(&user).Error)
+	for _, q := range []string{"q1", "q2", "q3", "q4", "q5", "q6"} {
+		version := 1
+		if q == "q1" {
+			version = 2
+		}
+		must(t, tx.Create(&models.Question{QuestionID: id(q), SubtestName: "addition", QuestionData: json.RawMessage(`{}`), AnswerData: "x", Version: version}).Error)
+	}
+	// Statistik lama untuk soal yang tidak lagi punya respons terhitung harus terhapus
+	must(t, tx.Create(&models.ItemStatistic{QuestionID: id("stale"), SubtestName: "addition", Exposure: 1, Distractors: json.RawMessage(`{}`), ComputedAt: time.Now().Add(-time.Hour)}).Error)
+
+	newTest := func(mode string) uint {
+		test := models.UserTest{UserID: user.ID, Mode: mode}
+		must(t, tx.Create(&test).Error)
+		return test.TestID
+	}
+	respond := func(testID uint, q string, version int, correct bool, answer string, ms int) {
+		must(t, tx.Create(&models.ItemResponse{TestID: testID, QuestionID: id(q), SubtestName: "addition", QuestionVersion: version,
+			Answer: answer, Correct: correct, ResponseTimeMs: ms}).Error)
+	}
+
+	// q1 dijawab benar oleh anak dengan skor tinggi (diskriminasi baik), q2 selalu benar, q3 selalu salah
+	for i, correct := range [][2]bool{{true, true}, {true, true}, {false, false}, {false, true}} {
+		testID := newTest(models.TestModeFixed)
+		respond(testID, "q1", 2, correct[0], map[bool]string{true: "21", false: "20"}[correct[0]], 1000*(i+1))
+		respond(testID, "q4", 1, correct[1], map[bool]string{true: "7", false: ""}[correct[1]], 0)
+		respond(testID, "q2", 1, true, "5", 0)
+		respond(testID, "q3", 1, false, "1", 0)
+	}
+	// Respons untuk versi lama q1 dan soal prosedural yang tidak ada di bank tidak dihitung
+	old := newTest(models.TestModeFixed)
+	respond(old, "q1", 1, false, "19", 0)
+	respond(old, "gen_1_medium_addition_1", 1, true, "3", 0)
+	// Tes adaptif dihitung exposure-nya tetapi tidak dipakai untuk point-biserial
+	for _, correct := range []bool{true, false} {
+		testID := newTest(models.TestModeAdaptive)
+		respond(testID, "q5", 1, correct, "", 0)
+		respond(testID, "q6", 1, correct, "", 0)
+	}
+
+	a := &Analyzer{DB: tx, Thresholds: Thresholds{MinExposure: 3, MinPCorrect: 0.2, MaxPCorrect: 0.9, MinDiscrimination: 0.2}}
+	if _, err := a.RunOnce(); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	var rows []models.ItemStatistic
+	must(t, tx.Where("question_id LIKE ?", prefix+"%").Order("question_id").Find(&rows).Error)
+	stats := map[string]models.ItemStatistic{}
+	for _, s := range rows {
+		stats[s.QuestionID] = s
+	}
+	if len(stats) != 6 {
+		t.Fatalf("got statistics for %d questions, want q1..q6: %+v", len(stats), rows)
+	}
+
+	tests := []struct {
+		question    string
+		version     int
+		exposure    int
+		pCorrect    float64
+		omitted     int
+		distractors map[string]int
+		flags       string
+		median      *float64
+		positivePB  bool
+	}{
+		{"q1", 2, 4, 0.5, 0, map[string]int{"20": 2}, "", ptr(2500), true},
+		{"q2", 1, 4, 1, 0, map[string]int{}, FlagTooEasy, nil, false},
+		{"q3", 1, 4, 0, 0, map[string]int{"1": 4}, FlagTooHard, nil, false},
+		{"q4", 1, 4, 0.75, 1, map[string]int{}, "", nil, true},
+		{"q5", 1, 2, 0.5, 1, map[string]int{}, "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			s := stats[id(tt.question)]
+			if s.QuestionVersion != tt.version || s.Exposure != tt.exposure || s.PCorrect != tt.pCorrect || s.Omitted != tt.omitted {
+				t.Errorf("version/exposure/p/omitted = %d/%d/%v/%d, want %d/%d/%v/%d", s.QuestionVersion, s.Exposure, s.PCorrect, s.Omitted,
+					tt.version, tt.exposure, tt.pCorrect, tt.omitted)
+			}
+			var distractors map[string]int
+			if err := json.Unmarshal(s.Distractors, &distractors); err != nil || len(distractors) != len(tt.distractors) {
+				t.Errorf("distractors = %s, want %v", s.Distractors, tt.distractors)
+			}
+			for answer, n := range tt.distractors {
+				if distractors[answer] != n {
+					t.Errorf("distractor %q = %d, want %d", answer, distractors[answer], n)
+				}
+			}
+			if s.Flags != tt.flags {
+				t.Errorf("flags = %q, want %q", s.Flags, tt.flags)
+			}
+			if (s.MedianResponseMs == nil) != (tt.median == nil) || (tt.median != nil && *s.MedianResponseMs != *tt.median) {
+				t.Errorf("median = %v, want %v", s.MedianResponseMs, tt.median)
+			}
+			if tt.positivePB && (s.PointBiserial == nil || *s.PointBiserial <= 0) {
+				t.Errorf("point-biserial = %v, want positive", s.PointBiserial)
+			}
+			if !tt.positivePB && s.PointBiserial != nil {
+				t.Errorf("point-biserial = %v, want nil", *s.PointBiserial)
+			}
+		})
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
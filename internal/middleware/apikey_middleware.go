@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"Dysec/internal/auth"
+	"Dysec/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyMiddleware memvalidasi header X-API-Key untuk integrasi server-to-server institusi
+func APIKeyMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "X-API-Key header is required"})
+			return
+		}
+
+		var apiKey models.APIKey
+		if err := db.Where("key_hash = ? AND revoked_at IS NULL", auth.HashToken(key)).First(&apiKey).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+
+		if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > lastSeenInterval {
+			db.Model(&apiKey).Update("last_used_at", time.Now())
+		}
+
+		c.Set("api_key", apiKey)
+		c.Set("organization_id", float64(apiKey.OrganizationID))
+		c.Next()
+	}
+}
+
+// RequireScope hanya meloloskan API key yang memiliki scope tertentu
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("api_key")
+		apiKey, ok := value.(models.APIKey)
+		if !ok || !apiKey.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is missing the required scope", "required_scope": scope})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"Dysec/internal/auth"
+	"Dysec/internal/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// lastSeenInterval membatasi seberapa sering last_seen_at session ditulis ke database
+const lastSeenInterval = time.Minute
+
+// JWTMiddleware memvalidasi access token aplikasi secara lokal, tanpa memanggil Google,
+// lalu memastikan session perangkatnya belum dicabut (logout)
+func JWTMiddleware(tokens *auth.TokenManager, db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		claims, err := tokens.ParseAccessToken(tokenString)
+		if err != nil {
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Access token has expired", "code": "token_expired"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+			return
+		}
+
+		var session models.Session
+		if err := db.Select("id", "revoked_at", "last_seen_at").First(&session, claims.SessionID).Error; err != nil || session.RevokedAt != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked", "code": "session_revoked"})
+			return
+		}
+		if time.Since(session.LastSeenAt) > lastSeenInterval {
+			db.Model(&session).Update("last_seen_at", time.Now())
+		}
+
+		c.Set("user_id", float64(claims.UserID))
+		c.Set("session_id", float64(claims.SessionID))
+		c.Set("role", claims.Role)
+		c.Next()
+	}
+}
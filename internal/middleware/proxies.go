@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// TrustProxies menentukan proxy yang boleh mengisi X-Forwarded-For. Tanpa ini gin mempercayai header
+// dari semua klien, sehingga c.ClientIP() (dipakai rate limit per IP, sesi, dan audit log) bisa dipalsukan.
+// Daftar kosong berarti server diakses langsung dan IP diambil dari koneksi TCP.
+func TrustProxies(router *gin.Engine, proxies []string) error {
+	if len(proxies) == 0 {
+		return router.SetTrustedProxies(nil)
+	}
+	return router.SetTrustedProxies(proxies)
+}
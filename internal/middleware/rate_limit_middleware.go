@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"Dysec/internal/ratelimit"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit menerapkan policy route dari rate_limit.policies per user dan per IP.
+// Pasang setelah JWTMiddleware agar user_id dan role tersedia.
+func RateLimit(limiter *ratelimit.Limiter, route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userKey string
+		if userID, exists := c.Get("user_id"); exists {
+			userKey = fmt.Sprintf("%.0f", userID.(float64))
+		}
+
+		allowed, retryAfter, err := limiter.Allow(c.Request.Context(), route, userKey, c.GetString("role"), c.ClientIP())
+		if err != nil {
+			// Gagal terbuka: gangguan penyimpanan limit tidak boleh mematikan API
+			log.Printf("ERROR: Rate limiter failed for route %s: %v", route, err)
+			c.Next()
+			return
+		}
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests, please try again later",
+				"retry_after": seconds,
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Dysec/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitedRouter(t *testing.T, proxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.RoutePolicy{
+		"auth": {IP: ratelimit.Policy{Rate: 1, Per: time.Hour, Burst: 1}},
+	})
+	router := gin.New()
+	if err := TrustProxies(router, proxies); err != nil {
+		t.Fatalf("TrustProxies: %v", err)
+	}
+	router.POST("/auth", RateLimit(limiter, "auth"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ip": c.ClientIP()})
+	})
+	return router
+}
+
+func post(router *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/auth", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitIgnoresForgedForwardedFor(t *testing.T) {
+	router := newRateLimitedRouter(t, nil)
+
+	if code := post(router, "203.0.113.7:1234", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("first request: got %d, want 200", code)
+	}
+	// Klien yang sama mengganti X-Forwarded-For; bucket IP-nya harus tetap sama
+	if code := post(router, "203.0.113.7:1234", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("forged X-Forwarded-For got a fresh bucket: got %d, want 429", code)
+	}
+}
+
+func TestRateLimitUsesForwardedForFromTrustedProxy(t *testing.T) {
+	router := newRateLimitedRouter(t, []string{"10.0.0.0/8"})
+
+	if code := post(router, "10.0.0.5:1234", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("client 1: got %d, want 200", code)
+	}
+	if code := post(router, "10.0.0.5:1234", "198.51.100.2"); code != http.StatusOK {
+		t.Fatalf("client 2 behind the same proxy: got %d, want 200", code)
+	}
+	if code := post(router, "10.0.0.5:1234", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("client 1 again: got %d, want 429", code)
+	}
+}
@@ -2,43 +2,338 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
+const (
+	RoleStudent   = "student"
+	RoleParent    = "parent"
+	RoleTeacher   = "teacher"
+	RoleClinician = "clinician"
+	RoleAdmin     = "admin"
+)
+
+var Roles = []string{RoleStudent, RoleParent, RoleTeacher, RoleClinician, RoleAdmin}
+
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type User struct {
 	ID         uint   `gorm:"primaryKey"`
 	GoogleID   string `gorm:"unique;not null"`
 	Email      string `gorm:"unique;not null"`
 	Name       string `gorm:"not null"`
 	PictureURL string
-	CreatedAt  time.Time
+	Role       string `gorm:"not null;default:student"`
+	// OrganizationID menghubungkan user ke sekolah/institusi mitra (opsional)
+	OrganizationID *uint `gorm:"index"`
+	CreatedAt      time.Time
 }
 
 type UserTest struct {
 	TestID            uint            `gorm:"primaryKey"`
 	UserID            uint            `gorm:"not null"`
+	ProfileID         *uint           `gorm:"index"`
 	AnswerKey         json.RawMessage `gorm:"type:jsonb"`
 	CorrectionResults json.RawMessage `gorm:"type:jsonb"`
-	CreatedAt         time.Time
+	// OrganizationID adalah organisasi user saat tes dimulai. API integrasi hanya membuka tes
+	// milik organisasi ini, bukan tes yang dikerjakan sebelum user bergabung.
+	OrganizationID *uint `gorm:"index"`
+	// PromptTemplateID dan PromptVersion menunjuk prompt yang menghasilkan soal tes ini (kosong jika dari bank soal)
+	PromptTemplateID string
+	PromptVersion    int
+	// Seed generator prosedural, hanya diisi jika seluruh soal tes prosedural sehingga tes bisa dibuat
+	// ulang dari Seed dan Difficulty (lihat itemgen)
+	Seed *int64
+	// Age, AgeBand, dan Difficulty dicatat saat tes dimulai (kosong jika usia/kelas tidak diketahui)
+	Age        *int
+	AgeBand    string
+	Difficulty string
+	// Mode tes: fixed (satu set soal, dikirim sekaligus) atau adaptive (soal diberikan satu per satu)
+	Mode string `gorm:"not null;default:fixed"`
+	// Estimasi ability dan standard error tes adaptif, diperbarui setiap respons
+	Ability   *float64
+	AbilitySE *float64
+	// QuestionVersions memetakan question_id soal bank ke versinya saat tes dimulai (hanya tes biasa)
+	QuestionVersions json.RawMessage `gorm:"type:jsonb"`
+	// PendingQuestionID adalah soal adaptif yang sedang ditampilkan dan belum dijawab,
+	// PendingQuestionVersion versinya saat ditampilkan
+	PendingQuestionID      string
+	PendingQuestionVersion int
+	CompletedAt            *time.Time
+	CreatedAt              time.Time
 
 	User    User    `gorm:"foreignKey:UserID"`
 	AiScore AiScore `gorm:"foreignKey:TestID"`
 }
 
+// ChildProfile adalah profil anak yang dikelola oleh akun wali (guardian)
+type ChildProfile struct {
+	ID         uint      `gorm:"primaryKey"`
+	GuardianID uint      `gorm:"not null;index"`
+	Name       string    `gorm:"not null"`
+	BirthDate  time.Time `gorm:"type:date;not null"`
+	Grade      string
+	CreatedAt  time.Time
+
+	Guardian User `gorm:"foreignKey:GuardianID" json:"-"`
+}
+
+// AgeAt menghitung usia (tahun penuh) profil pada waktu t
+func (p ChildProfile) AgeAt(t time.Time) int {
+	age := t.Year() - p.BirthDate.Year()
+	if t.Month() < p.BirthDate.Month() || (t.Month() == p.BirthDate.Month() && t.Day() < p.BirthDate.Day()) {
+		age--
+	}
+	return age
+}
+
 type AiScore struct {
-	ID                    uint            `gorm:"primaryKey"`
-	TestID                uint            `gorm:"unique;not null"`
-	Diagnosis             int             `gorm:"not null"`
-	FinalDyscalculiaScore float64         `gorm:"not null"`
+	ID                    uint    `gorm:"primaryKey"`
+	TestID                uint    `gorm:"unique;not null"`
+	Diagnosis             int     `gorm:"not null"`
+	FinalDyscalculiaScore float64 `gorm:"not null"`
+	ModelVersion          string
 	RawResponse           json.RawMessage `gorm:"type:jsonb"`
 	CreatedAt             time.Time
+	// UpdatedAt berubah setiap kali skor ditulis ulang (mis. submit ulang setelah scoring gagal)
+	UpdatedAt time.Time `gorm:"index"`
+}
+
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+var Difficulties = []string{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+func IsValidDifficulty(difficulty string) bool {
+	for _, d := range Difficulties {
+		if d == difficulty {
+			return true
+		}
+	}
+	return false
 }
 
 type Question struct {
-	ID           uint            `gorm:"primaryKey"`
-	SubtestName  string          `gorm:"not null"`
-	QuestionID   string          `gorm:"unique;not null"`
+	ID          uint   `gorm:"primaryKey"`
+	SubtestName string `gorm:"not null;index:idx_questions_stock"`
+	// QuestionID diberikan server (lihat questionbank.NewQuestionID), bukan ID lokal dari AI
+	QuestionID   string          `gorm:"not null;uniqueIndex:idx_questions_question_id"`
 	QuestionData json.RawMessage `gorm:"type:jsonb;not null"`
 	AnswerData   string          `gorm:"not null"`
+	Difficulty   string          `gorm:"not null;default:medium;index:idx_questions_stock"`
+	// Parameter IRT 2PL untuk tes adaptif: discrimination (a) dan difficulty (b) pada skala ability
+	IRTDiscrimination float64 `gorm:"not null;default:1"`
+	IRTDifficulty     float64 `gorm:"not null;default:0"`
+	// Status active dipakai saat menyusun tes; retired disimpan untuk riwayat tetapi tidak lagi diberikan
+	Status string `gorm:"not null;default:active;index"`
+	// Version naik setiap kali soal diedit; versi sebelumnya disimpan di QuestionVersion
+	Version int `gorm:"not null;default:1"`
+	// ContentHash adalah testset.ContentHash isi soal; soal aktif dengan isi yang sama tidak boleh ganda
+	ContentHash string `gorm:"not null;default:'';uniqueIndex:idx_questions_content_hash,where:status = 'active' AND content_hash <> ''"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+const (
+	QuestionStatusActive  = "active"
+	QuestionStatusRetired = "retired"
+)
+
+var QuestionStatuses = []string{QuestionStatusActive, QuestionStatusRetired}
+
+func IsValidQuestionStatus(status string) bool {
+	for _, s := range QuestionStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// QuestionVersion adalah salinan soal sebelum diedit, agar tes lama tetap bisa ditelusuri ke isi soal aslinya
+type QuestionVersion struct {
+	ID                uint            `gorm:"primaryKey"`
+	QuestionRefID     uint            `gorm:"not null;uniqueIndex:idx_question_versions_version"`
+	Version           int             `gorm:"not null;uniqueIndex:idx_question_versions_version"`
+	QuestionID        string          `gorm:"not null"`
+	SubtestName       string          `gorm:"not null"`
+	QuestionData      json.RawMessage `gorm:"type:jsonb;not null"`
+	AnswerData        string          `gorm:"not null"`
+	Difficulty        string          `gorm:"not null"`
+	IRTDiscrimination float64
+	IRTDifficulty     float64
+	Status            string `gorm:"not null"`
+	EditedBy          uint
+	CreatedAt         time.Time
+}
+
+const (
+	TestModeFixed    = "fixed"
+	TestModeAdaptive = "adaptive"
+)
+
+// ItemResponse adalah jawaban untuk satu soal dalam sebuah tes
+type ItemResponse struct {
+	ID          uint   `gorm:"primaryKey"`
+	TestID      uint   `gorm:"not null;uniqueIndex:idx_item_responses_test_question"`
+	QuestionID  string `gorm:"not null;uniqueIndex:idx_item_responses_test_question;index"`
+	SubtestName string `gorm:"not null"`
+	// QuestionVersion adalah versi soal yang ditampilkan saat respons diberikan
+	QuestionVersion int `gorm:"not null;default:1"`
+	Position        int `gorm:"not null"`
+	Answer          string
+	Correct         bool `gorm:"not null"`
+	ResponseTimeMs  int
+	// Estimasi ability setelah respons ini (hanya tes adaptif)
+	AbilityAfter *float64
+	SEAfter      *float64
 	CreatedAt    time.Time
 }
+
+// ItemStatistic adalah hasil analisis soal dari ItemResponse, dihitung ulang secara berkala.
+// PointBiserial dan MedianResponseMs nil jika datanya belum cukup.
+type ItemStatistic struct {
+	QuestionID string `gorm:"primaryKey"`
+	// QuestionVersion adalah versi soal yang dianalisis; respons untuk versi sebelumnya tidak dihitung
+	QuestionVersion  int    `gorm:"not null;default:1"`
+	SubtestName      string `gorm:"not null;index"`
+	Exposure         int    `gorm:"not null"`
+	PCorrect         float64
+	PointBiserial    *float64
+	MedianResponseMs *float64
+	Omitted          int
+	// Distractors adalah jumlah setiap jawaban salah, mis. {"12": 4, "21": 1}
+	Distractors json.RawMessage `gorm:"type:jsonb"`
+	Flags       string          // dipisah spasi, mis. "too_easy low_discrimination"
+	ComputedAt  time.Time
+}
+
+// PromptTemplate adalah satu versi prompt yang disimpan di database. Baris tidak pernah diubah;
+// setiap perubahan menjadi versi baru agar tes lama tetap bisa ditelusuri ke prompt aslinya.
+type PromptTemplate struct {
+	ID         uint   `gorm:"primaryKey"`
+	TemplateID string `gorm:"not null;uniqueIndex:idx_prompt_template_version"`
+	Version    int    `gorm:"not null;uniqueIndex:idx_prompt_template_version"`
+	Body       string `gorm:"type:text;not null"`
+	CreatedBy  uint
+	CreatedAt  time.Time
+}
+
+// AIUsage adalah satu baris ledger pemakaian AI: satu panggilan ke provider.
+// UserID dan TestID kosong untuk panggilan dari worker bank soal.
+type AIUsage struct {
+	ID               uint   `gorm:"primaryKey"`
+	UserID           *uint  `gorm:"index"`
+	TestID           *uint  `gorm:"index"`
+	Purpose          string `gorm:"not null"`
+	Provider         string `gorm:"not null"`
+	Model            string `gorm:"not null;index"`
+	PromptTokens     int    `gorm:"not null"`
+	CompletionTokens int    `gorm:"not null"`
+	TotalTokens      int    `gorm:"not null"`
+	CostUSD          float64
+	LatencyMs        int64
+	CreatedAt        time.Time `gorm:"index"`
+}
+
+// Session mewakili satu perangkat yang sedang login. Refresh token selalu terikat ke satu session.
+type Session struct {
+	ID         uint `gorm:"primaryKey"`
+	UserID     uint `gorm:"not null;index"`
+	DeviceID   string
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+	LastSeenAt time.Time
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+type RefreshToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	SessionID uint      `gorm:"index"`
+	TokenHash string    `gorm:"unique;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// AuditLog mencatat aksi sensitif (penghapusan akun, perubahan role, dsb.)
+type AuditLog struct {
+	ID         uint   `gorm:"primaryKey"`
+	ActorID    *uint  `gorm:"index"`
+	Action     string `gorm:"not null;index"`
+	TargetType string
+	TargetID   string
+	Details    json.RawMessage `gorm:"type:jsonb"`
+	IPAddress  string
+	CreatedAt  time.Time
+}
+
+// DeletionRequest adalah langkah pertama penghapusan akun yang harus dikonfirmasi.
+// Permintaan yang dikonfirmasi ikut terhapus bersama akunnya.
+type DeletionRequest struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"unique;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+// Organization adalah institusi mitra (mis. sekolah) yang mengakses data lewat API key
+type Organization struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+const (
+	ScopeTestsRead   = "tests:read"
+	ScopeResultsRead = "results:read"
+)
+
+var APIKeyScopes = []string{ScopeTestsRead, ScopeResultsRead}
+
+// APIKey untuk integrasi server-to-server. Hanya hash key yang disimpan; Prefix ditampilkan ke admin.
+type APIKey struct {
+	ID             uint   `gorm:"primaryKey"`
+	OrganizationID uint   `gorm:"not null;index"`
+	Name           string `gorm:"not null"`
+	Prefix         string `gorm:"unique;not null"`
+	KeyHash        string `gorm:"unique;not null" json:"-"`
+	Scopes         string `gorm:"not null"` // dipisah spasi, mis. "tests:read results:read"
+	CreatedBy      uint
+	LastUsedAt     *time.Time
+	RevokedAt      *time.Time
+	CreatedAt      time.Time
+}
+
+// HasScope memeriksa apakah API key memiliki scope tertentu
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Fields(k.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitBucket adalah state token bucket untuk backend rate limit Postgres
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false"`
+}
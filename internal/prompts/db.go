@@ -0,0 +1,139 @@
+package prompts
+
+import (
+	"Dysec/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict berarti nomor versi yang sama ada di database dan di file template dengan isi berbeda,
+// sehingga "template vN" tidak lagi menunjuk satu prompt
+var ErrVersionConflict = errors.New("prompt template version exists in both the database and template files")
+
+// DBStore membaca template dari tabel prompt_templates sehingga kata-kata prompt
+// bisa diubah lewat admin API tanpa deploy ulang. Database dan file cadangan berbagi satu
+// urutan versi: versi aktif adalah versi tertinggi dari keduanya, sehingga template file
+// baru yang ikut deploy tetap terpakai walaupun database sudah punya versi lama.
+type DBStore struct {
+	db       *gorm.DB
+	fallback Store
+}
+
+// NewDBStore membuat DB store. fallback dipakai jika template belum pernah disimpan di database.
+func NewDBStore(db *gorm.DB, fallback Store) *DBStore {
+	return &DBStore{db: db, fallback: fallback}
+}
+
+func (s *DBStore) Latest(id string) (*Template, error) {
+	var row models.PromptTemplate
+	err := s.db.Where("template_id = ?", id).Order("version desc").First(&row).Error
+	stored, err := s.stored(row, err)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.fromFallback(func(f Store) (*Template, error) { return f.Latest(id) })
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case stored == nil && file == nil:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	case file == nil || (stored != nil && stored.Version > file.Version):
+		return stored, nil
+	case stored == nil || file.Version > stored.Version:
+		return file, nil
+	}
+	return resolve(stored, file)
+}
+
+func (s *DBStore) Get(id string, version int) (*Template, error) {
+	var row models.PromptTemplate
+	err := s.db.Where("template_id = ? AND version = ?", id, version).First(&row).Error
+	stored, err := s.stored(row, err)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.fromFallback(func(f Store) (*Template, error) { return f.Get(id, version) })
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case stored == nil && file == nil:
+		return nil, fmt.Errorf("%w: %s v%d", ErrNotFound, id, version)
+	case file == nil:
+		return stored, nil
+	case stored == nil:
+		return file, nil
+	}
+	return resolve(stored, file)
+}
+
+// stored mengubah hasil query menjadi template; nil tanpa error jika baris tidak ada
+func (s *DBStore) stored(row models.PromptTemplate, err error) (*Template, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Template{ID: row.TemplateID, Version: row.Version, Body: row.Body}, nil
+}
+
+// fromFallback membaca template dari store cadangan; nil tanpa error jika tidak ada
+func (s *DBStore) fromFallback(get func(Store) (*Template, error)) (*Template, error) {
+	if s.fallback == nil {
+		return nil, nil
+	}
+	t, err := get(s.fallback)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return t, err
+}
+
+// resolve menangani versi yang sama di database dan file. Isi yang identik tidak ambigu;
+// isi yang berbeda ditolak.
+func resolve(stored, file *Template) (*Template, error) {
+	if stored.Body != file.Body {
+		return nil, fmt.Errorf("%w: %s v%d", ErrVersionConflict, stored.ID, stored.Version)
+	}
+	return stored, nil
+}
+
+// Create menyimpan body sebagai versi baru template id. Nomor versi melanjutkan versi tertinggi
+// di database maupun file cadangan, sehingga versi baru tidak pernah memakai nomor versi file.
+func (s *DBStore) Create(id, body string, createdBy uint) (*models.PromptTemplate, error) {
+	if _, err := Parse(id, body); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	row := models.PromptTemplate{TemplateID: id, Body: body, CreatedBy: createdBy}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		latest, err := NewDBStore(tx, s.fallback).Latest(id)
+		switch {
+		case err == nil:
+			row.Version = latest.Version + 1
+		case errors.Is(err, ErrNotFound):
+			row.Version = 1
+		default:
+			return err
+		}
+		// Unique index (template_id, version) menolak dua versi sama yang dibuat bersamaan
+		return tx.Create(&row).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// Versions mengembalikan semua versi template id yang tersimpan di database, terbaru lebih dulu
+func (s *DBStore) Versions(id string) ([]models.PromptTemplate, error) {
+	var rows []models.PromptTemplate
+	err := s.db.Where("template_id = ?", id).Order("version desc").Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,63 @@
+package prompts
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// FileStore membaca template dari file bernama <id>.v<versi>.tmpl
+type FileStore struct {
+	fsys fs.FS
+}
+
+// NewFileStore membuat file store dari direktori dir. dir kosong berarti template bawaan.
+func NewFileStore(dir string) *FileStore {
+	if dir == "" {
+		sub, _ := fs.Sub(builtin, "templates")
+		return &FileStore{fsys: sub}
+	}
+	return &FileStore{fsys: os.DirFS(dir)}
+}
+
+func (s *FileStore) Latest(id string) (*Template, error) {
+	matches, err := fs.Glob(s.fsys, id+".v*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	latest := 0
+	for _, name := range matches {
+		if version, ok := parseVersion(id, name); ok && version > latest {
+			latest = version
+		}
+	}
+	if latest == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return s.Get(id, latest)
+}
+
+func (s *FileStore) Get(id string, version int) (*Template, error) {
+	body, err := fs.ReadFile(s.fsys, fmt.Sprintf("%s.v%d.tmpl", id, version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s v%d", ErrNotFound, id, version)
+		}
+		return nil, err
+	}
+	return &Template{ID: id, Version: version, Body: string(body)}, nil
+}
+
+func parseVersion(id, name string) (int, bool) {
+	v := strings.TrimSuffix(strings.TrimPrefix(path.Base(name), id+".v"), ".tmpl")
+	version, err := strconv.Atoi(v)
+	return version, err == nil && version > 0
+}
@@ -0,0 +1,150 @@
+package prompts
+
+import (
+	"Dysec/internal/itemgen"
+	"Dysec/internal/models"
+	"Dysec/internal/testset"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// TestGeneration adalah ID template default untuk prompt pembuatan soal tes
+const TestGeneration = "test_generation"
+
+var ErrNotFound = errors.New("prompt template not found")
+
+// Template adalah satu versi prompt. Versi tidak pernah diubah setelah dibuat;
+// perubahan kata-kata selalu menghasilkan versi baru.
+type Template struct {
+	ID      string `json:"template_id"`
+	Version int    `json:"version"`
+	Body    string `json:"body"`
+}
+
+// SubtestCount adalah jumlah soal satu subtes, berurutan agar hasil render stabil
+type SubtestCount struct {
+	Name  string
+	Count int
+}
+
+// Vars adalah variabel yang tersedia di dalam template. Params berisi rentang angka untuk
+// tingkat kesulitan (sama dengan generator prosedural), nil jika kesulitan tidak ditentukan.
+type Vars struct {
+	Subtests   []SubtestCount
+	Difficulty string
+	AgeBand    string
+	Locale     string
+	Params     *itemgen.Params
+}
+
+// NewVars menyusun Vars dengan urutan subtes mengikuti testset.ItemSubtests
+func NewVars(counts map[string]int, difficulty, ageBand, locale string) Vars {
+	vars := Vars{Difficulty: difficulty, AgeBand: ageBand, Locale: locale}
+	if params, ok := itemgen.DefaultParams[difficulty]; ok {
+		vars.Params = &params
+	}
+	for _, subtest := range testset.ItemSubtests {
+		vars.Subtests = append(vars.Subtests, SubtestCount{Name: subtest, Count: counts[subtest]})
+	}
+	return vars
+}
+
+// Store mengambil template berdasarkan ID. Latest mengembalikan versi tertinggi.
+type Store interface {
+	Latest(id string) (*Template, error)
+	Get(id string, version int) (*Template, error)
+}
+
+// NewStoreFromConfig membaca prompts.store (file|db) dan prompts.dir.
+// Tanpa prompts.dir, file store memakai template bawaan yang ikut di-compile.
+// DB store memakai file store sebagai cadangan jika template belum ada di database.
+func NewStoreFromConfig(db *gorm.DB) (Store, error) {
+	files := NewFileStore(viper.GetString("prompts.dir"))
+
+	switch backend := strings.ToLower(viper.GetString("prompts.store")); backend {
+	case "", "file":
+		return files, nil
+	case "db":
+		return NewDBStore(db, files), nil
+	default:
+		return nil, fmt.Errorf("unknown prompts.store %q", backend)
+	}
+}
+
+// Parse mem-parsing body template. Variabel yang tidak dikenal dianggap error.
+func Parse(id, body string) (*template.Template, error) {
+	return template.New(id).Option("missingkey=error").Parse(body)
+}
+
+// Execute merender template dengan vars
+func (t *Template) Execute(vars Vars) (string, error) {
+	tmpl, err := Parse(t.ID, t.Body)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template %s v%d: %w", t.ID, t.Version, err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s v%d: %w", t.ID, t.Version, err)
+	}
+	return b.String(), nil
+}
+
+// Renderer merender prompt pembuatan soal dari template versi terbaru
+type Renderer struct {
+	Store      Store
+	TemplateID string
+	Locale     string
+}
+
+// NewRendererFromConfig membaca prompts.* (store, dir, test_generation_id, locale)
+func NewRendererFromConfig(db *gorm.DB) (*Renderer, error) {
+	store, err := NewStoreFromConfig(db)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{
+		Store:      store,
+		TemplateID: viper.GetString("prompts.test_generation_id"),
+		Locale:     viper.GetString("prompts.locale"),
+	}
+	if r.TemplateID == "" {
+		r.TemplateID = TestGeneration
+	}
+	if r.Locale == "" {
+		r.Locale = "id"
+	}
+
+	// Pastikan template bisa dimuat dan dirender saat startup, bukan saat tes pertama dimulai
+	_, t, err := r.TestPrompt(testset.DefaultCounts, models.DifficultyMedium, "8-9")
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Prompt template loaded: %s v%d (%T)", t.ID, t.Version, store)
+	return r, nil
+}
+
+// TestPrompt merender prompt pembuatan soal dan mengembalikan template yang dipakai
+func (r *Renderer) TestPrompt(counts map[string]int, difficulty, ageBand string) (string, *Template, error) {
+	return Render(r.Store, r.TemplateID, NewVars(counts, difficulty, ageBand, r.Locale))
+}
+
+// Render mengambil versi terbaru template id lalu merendernya
+func Render(store Store, id string, vars Vars) (string, *Template, error) {
+	t, err := store.Latest(id)
+	if err != nil {
+		return "", nil, err
+	}
+	text, err := t.Execute(vars)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, t, nil
+}
@@ -0,0 +1,193 @@
+package questionbank
+
+import (
+	"Dysec/internal/cat"
+	"Dysec/internal/models"
+	"Dysec/internal/testset"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewQuestionID membuat ID soal yang diberikan server. ID tidak diturunkan dari isi soal
+// sehingga tetap sama walaupun soal diedit.
+func NewQuestionID() string {
+	b := make([]byte, 12)
+	rand.Read(b) // sejak Go 1.24 crypto/rand.Read tidak pernah mengembalikan error
+	return "q_" + hex.EncodeToString(b)
+}
+
+// Save menyimpan soal hasil generator yang sudah tervalidasi ke bank soal, lalu mengganti ID lokal
+// dari AI (mis. "add_1") di generated dengan ID server. ID lokal hanya berlaku di dalam satu sesi
+// generate, sehingga answer key tes yang dibuat dari generated selalu cocok dengan soal di bank.
+// Soal yang isinya sama dengan soal aktif di bank (ContentHash sama) memakai ID soal tersebut.
+// Mengembalikan jumlah soal baru.
+func Save(db *gorm.DB, generated *testset.Generated, difficulty string) int {
+	saved := 0
+	ids := map[string]string{}
+	inBatch := map[string]bool{}
+	for subtestName, items := range generated.Items() {
+		for _, item := range items {
+			localID := item.ID()
+			// Default: ID server baru yang hanya dipakai sesi ini jika soal tidak bisa disimpan
+			ids[localID] = NewQuestionID()
+
+			qData, err := testset.PublicJSON(item)
+			if err != nil {
+				log.Printf("ERROR: Failed to encode question %s: %v", localID, err)
+				continue
+			}
+			hash, err := testset.ContentHash(subtestName, qData)
+			if err != nil {
+				log.Printf("ERROR: Failed to hash question %s: %v", localID, err)
+				continue
+			}
+			// Soal kembar dalam satu batch tidak disimpan dua kali dan tidak berbagi ID di tes yang sama
+			if inBatch[hash] {
+				continue
+			}
+			inBatch[hash] = true
+
+			if existing, err := findByHash(db, hash); err == nil {
+				log.Printf("INFO: Question %s has the same content as %s, reusing it.", localID, existing.QuestionID)
+				ids[localID] = existing.QuestionID
+				continue
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("ERROR: DB check failed for question %s: %v", localID, err)
+				continue
+			}
+
+			newQuestion := models.Question{
+				SubtestName:  subtestName,
+				QuestionID:   ids[localID],
+				QuestionData: withQuestionID(qData, ids[localID]),
+				AnswerData:   item.AnswerValue(),
+				Difficulty:   difficulty,
+				Status:       models.QuestionStatusActive,
+				Version:      1,
+				ContentHash:  hash,
+				// Parameter IRT awal dari label kesulitan sampai item dikalibrasi
+				IRTDiscrimination: 1,
+				IRTDifficulty:     cat.DefaultDifficulty(difficulty),
+			}
+			// Request lain bisa menyimpan soal yang sama di antara pengecekan dan insert
+			result := db.Clauses(clause.OnConflict{
+				Columns:     []clause.Column{{Name: "content_hash"}},
+				TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: activeHashCondition}}},
+				DoNothing:   true,
+			}).Create(&newQuestion)
+			if result.Error != nil {
+				log.Printf("ERROR: Failed to create new question %s: %v", localID, result.Error)
+				continue
+			}
+			if result.RowsAffected == 0 {
+				if existing, err := findByHash(db, hash); err == nil {
+					ids[localID] = existing.QuestionID
+				}
+				continue
+			}
+			saved++
+		}
+	}
+	generated.SetIDs(ids)
+	return saved
+}
+
+// activeHashCondition sama dengan kondisi index unik idx_questions_content_hash
+const activeHashCondition = "status = 'active' AND content_hash <> ''"
+
+// findByHash mencari soal aktif dengan isi yang sama. Soal yang di-retire sengaja tidak dipakai ulang:
+// isi yang di-generate lagi disimpan sebagai soal aktif baru agar bisa keluar di tes.
+func findByHash(db *gorm.DB, hash string) (*models.Question, error) {
+	var q models.Question
+	err := db.Where("content_hash = ? AND status = ?", hash, models.QuestionStatusActive).
+		Order("id ASC").
+		First(&q).Error
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// FindDuplicate mencari soal aktif lain dengan isi yang sama dengan q
+func FindDuplicate(db *gorm.DB, q *models.Question) (*models.Question, error) {
+	if q.Status != models.QuestionStatusActive || q.ContentHash == "" {
+		return nil, nil
+	}
+	var dup models.Question
+	err := db.Where("content_hash = ? AND status = ? AND question_id <> ?", q.ContentHash, models.QuestionStatusActive, q.QuestionID).
+		First(&dup).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dup, nil
+}
+
+// withQuestionID mengganti question_id di dalam data soal
+func withQuestionID(data json.RawMessage, id string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return data
+	}
+	fields["question_id"], _ = json.Marshal(id)
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// Assemble menyusun satu sesi tes dari bank soal secara acak. difficulty kosong berarti semua tingkat.
+// complete bernilai false jika ada subtes yang stoknya kurang dari jumlah yang diminta.
+func Assemble(db *gorm.DB, counts map[string]int, difficulty string) (set testset.TestSet, complete bool, err error) {
+	set = testset.NewTestSet()
+	complete = true
+	for subtestName, count := range counts {
+		query := db.Where("subtest_name = ? AND status = ?", subtestName, models.QuestionStatusActive)
+		if difficulty != "" {
+			query = query.Where("difficulty = ?", difficulty)
+		}
+
+		var randomQuestions []models.Question
+		if err := query.Order("RANDOM()").Limit(count).Find(&randomQuestions).Error; err != nil {
+			return nil, false, err
+		}
+		for _, q := range randomQuestions {
+			set.Add(subtestName, q.QuestionID, q.QuestionData, q.AnswerData)
+		}
+		if len(randomQuestions) < count {
+			complete = false
+		}
+	}
+	return set, complete, nil
+}
+
+// Stock menghitung jumlah soal aktif per subtes untuk satu tingkat kesulitan
+func Stock(db *gorm.DB, difficulty string) (map[string]int, error) {
+	var rows []struct {
+		SubtestName string
+		Total       int
+	}
+	err := db.Model(&models.Question{}).
+		Select("subtest_name, COUNT(*) AS total").
+		Where("difficulty = ? AND status = ?", difficulty, models.QuestionStatusActive).
+		Group("subtest_name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stock := map[string]int{}
+	for _, r := range rows {
+		stock[r.SubtestName] = r.Total
+	}
+	return stock, nil
+}
@@ -0,0 +1,498 @@
+package questionbank
+
+import (
+	"Dysec/internal/cat"
+	"Dysec/internal/models"
+	"Dysec/internal/testset"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var (
+	ErrInvalidFormat = errors.New("format must be csv or json")
+	ErrConflict      = errors.New("question was modified concurrently")
+	errRollback      = errors.New("rollback import")
+)
+
+// Record adalah satu soal dalam format yang ditulis admin (API, CSV, atau JSON).
+// QuestionData tidak berisi answer; kunci jawaban ada di Answer.
+type Record struct {
+	QuestionID   string          `json:"question_id"`
+	SubtestName  string          `json:"subtest_name"`
+	QuestionData json.RawMessage `json:"question_data"`
+	Answer       string          `json:"answer"`
+	Difficulty   string          `json:"difficulty"`
+	Status       string          `json:"status"`
+	// Parameter IRT; jika kosong dipertahankan, atau diturunkan dari difficulty untuk soal baru
+	IRTDiscrimination *float64 `json:"irt_discrimination,omitempty"`
+	IRTDifficulty     *float64 `json:"irt_difficulty,omitempty"`
+}
+
+// RecordOf mengubah soal di bank ke Record, kebalikan dari Apply
+func RecordOf(q models.Question) Record {
+	return Record{
+		QuestionID:        q.QuestionID,
+		SubtestName:       q.SubtestName,
+		QuestionData:      q.QuestionData,
+		Answer:            q.AnswerData,
+		Difficulty:        q.Difficulty,
+		Status:            q.Status,
+		IRTDiscrimination: &q.IRTDiscrimination,
+		IRTDifficulty:     &q.IRTDifficulty,
+	}
+}
+
+// Apply memvalidasi record dengan pemeriksaan yang sama seperti soal hasil generator lalu menyalinnya
+// ke q. Difficulty dan status yang kosong diberi default untuk soal baru dan dipertahankan untuk soal
+// lama, sehingga edit atau impor tanpa kolom tersebut tidak mengaktifkan lagi soal yang di-retire.
+// Error validasi dikembalikan sebagai *testset.ValidationError.
+func (r Record) Apply(q *models.Question) error {
+	if r.Difficulty == "" {
+		r.Difficulty = q.Difficulty
+		if q.ID == 0 {
+			r.Difficulty = models.DifficultyMedium
+		}
+	}
+	if !models.IsValidDifficulty(r.Difficulty) {
+		return &testset.ValidationError{Problems: []string{fmt.Sprintf("difficulty must be one of %v", models.Difficulties)}}
+	}
+	if r.Status == "" {
+		r.Status = q.Status
+		if q.ID == 0 {
+			r.Status = models.QuestionStatusActive
+		}
+	}
+	if !models.IsValidQuestionStatus(r.Status) {
+		return &testset.ValidationError{Problems: []string{fmt.Sprintf("status must be one of %v", models.QuestionStatuses)}}
+	}
+
+	item, err := testset.ParseItem(r.SubtestName, q.QuestionID, r.QuestionData, r.Answer)
+	if err != nil {
+		return err
+	}
+	data, err := testset.PublicJSON(item)
+	if err != nil {
+		return err
+	}
+	hash, err := testset.ContentHash(r.SubtestName, data)
+	if err != nil {
+		return err
+	}
+
+	// Parameter IRT awal dari label kesulitan, kecuali soal lama yang kesulitannya tidak berubah
+	if q.ID == 0 || q.Difficulty != r.Difficulty {
+		q.IRTDiscrimination = 1
+		q.IRTDifficulty = cat.DefaultDifficulty(r.Difficulty)
+	}
+	if r.IRTDiscrimination != nil {
+		q.IRTDiscrimination = *r.IRTDiscrimination
+	}
+	if r.IRTDifficulty != nil {
+		q.IRTDifficulty = *r.IRTDifficulty
+	}
+
+	q.SubtestName = r.SubtestName
+	q.QuestionData = data
+	q.AnswerData = item.AnswerValue()
+	q.Difficulty = r.Difficulty
+	q.Status = r.Status
+	q.ContentHash = hash
+	return nil
+}
+
+// Update menyimpan isi soal sebelumnya sebagai QuestionVersion lalu menulis updated dengan versi
+// berikutnya. Update bersyarat pada versi agar dua edit bersamaan tidak saling menimpa (ErrConflict).
+func Update(tx *gorm.DB, previous models.Question, updated *models.Question, editedBy uint) error {
+	snapshot := models.QuestionVersion{
+		QuestionRefID:     previous.ID,
+		Version:           previous.Version,
+		QuestionID:        previous.QuestionID,
+		SubtestName:       previous.SubtestName,
+		QuestionData:      previous.QuestionData,
+		AnswerData:        previous.AnswerData,
+		Difficulty:        previous.Difficulty,
+		IRTDiscrimination: previous.IRTDiscrimination,
+		IRTDifficulty:     previous.IRTDifficulty,
+		Status:            previous.Status,
+		EditedBy:          editedBy,
+	}
+	if err := tx.Create(&snapshot).Error; err != nil {
+		return err
+	}
+
+	updated.Version = previous.Version + 1
+	result := tx.Model(&models.Question{}).
+		Where("id = ? AND version = ?", previous.ID, previous.Version).
+		Updates(map[string]interface{}{
+			"subtest_name":       updated.SubtestName,
+			"question_data":      updated.QuestionData,
+			"answer_data":        updated.AnswerData,
+			"difficulty":         updated.Difficulty,
+			"status":             updated.Status,
+			"irt_discrimination": updated.IRTDiscrimination,
+			"irt_difficulty":     updated.IRTDifficulty,
+			"version":            updated.Version,
+			"content_hash":       updated.ContentHash,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func sameContent(a, b models.Question) bool {
+	return a.SubtestName == b.SubtestName &&
+		sameJSON(a.QuestionData, b.QuestionData) &&
+		a.AnswerData == b.AnswerData &&
+		a.Difficulty == b.Difficulty &&
+		a.Status == b.Status &&
+		a.IRTDiscrimination == b.IRTDiscrimination &&
+		a.IRTDifficulty == b.IRTDifficulty
+}
+
+// sameJSON membandingkan isi JSON, karena jsonb di Postgres tidak mempertahankan urutan key dan spasi
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// Filter membatasi soal berdasarkan subtes, status, dan kesulitan; field kosong berarti semua
+type Filter struct {
+	Subtest    string
+	Status     string
+	Difficulty string
+}
+
+// Scope menerapkan filter ke query
+func (f Filter) Scope(query *gorm.DB) *gorm.DB {
+	if f.Subtest != "" {
+		query = query.Where("subtest_name = ?", f.Subtest)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Difficulty != "" {
+		query = query.Where("difficulty = ?", f.Difficulty)
+	}
+	return query
+}
+
+// Row adalah satu baris file import. Number adalah nomor baris di CSV (header = 1)
+// atau urutan elemen di JSON (mulai dari 1). Err diisi jika baris tidak bisa dibaca.
+type Row struct {
+	Number int
+	Record Record
+	Err    error
+}
+
+// RowError adalah semua masalah pada satu baris import
+type RowError struct {
+	Row        int      `json:"row"`
+	QuestionID string   `json:"question_id,omitempty"`
+	Problems   []string `json:"problems"`
+}
+
+// ImportReport merangkum hasil import. Jika Errors tidak kosong, tidak ada baris yang disimpan.
+type ImportReport struct {
+	DryRun    bool       `json:"dry_run"`
+	Total     int        `json:"total"`
+	Created   int        `json:"created"`
+	Updated   int        `json:"updated"`
+	Unchanged int        `json:"unchanged"`
+	Errors    []RowError `json:"errors"`
+}
+
+// Import melakukan upsert berdasarkan question_id dalam satu transaksi. Soal yang berubah mendapat
+// versi baru seperti edit lewat API. Import bersifat semua-atau-tidak-sama-sekali: jika ada baris yang
+// tidak valid, atau dryRun, transaksi dibatalkan dan laporan tetap berisi hitungan yang akan terjadi.
+func Import(db *gorm.DB, rows []Row, dryRun bool, editedBy uint) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, Total: len(rows), Errors: []RowError{}}
+	rowError := func(row Row, err error) {
+		re := RowError{Row: row.Number, QuestionID: row.Record.QuestionID}
+		var verr *testset.ValidationError
+		if errors.As(err, &verr) {
+			re.Problems = verr.Problems
+		} else {
+			re.Problems = []string{err.Error()}
+		}
+		report.Errors = append(report.Errors, re)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		seen := map[string]int{}
+		for _, row := range rows {
+			if row.Err != nil {
+				rowError(row, row.Err)
+				continue
+			}
+			id := row.Record.QuestionID
+			if id == "" {
+				rowError(row, errors.New("question_id is required"))
+				continue
+			}
+			if first, ok := seen[id]; ok {
+				rowError(row, fmt.Errorf("question_id %q is already used on row %d", id, first))
+				continue
+			}
+			seen[id] = row.Number
+
+			var existing models.Question
+			err := tx.Where("question_id = ?", id).First(&existing).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				question := models.Question{QuestionID: id, Version: 1}
+				if err := row.Record.Apply(&question); err != nil {
+					rowError(row, err)
+					continue
+				}
+				if dup, err := FindDuplicate(tx, &question); err != nil {
+					return err
+				} else if dup != nil {
+					rowError(row, fmt.Errorf("same content as question %s", dup.QuestionID))
+					continue
+				}
+				if err := tx.Create(&question).Error; err != nil {
+					return err
+				}
+				report.Created++
+			case err != nil:
+				return err
+			default:
+				updated := existing
+				if err := row.Record.Apply(&updated); err != nil {
+					rowError(row, err)
+					continue
+				}
+				if sameContent(existing, updated) {
+					report.Unchanged++
+					continue
+				}
+				if dup, err := FindDuplicate(tx, &updated); err != nil {
+					return err
+				} else if dup != nil {
+					rowError(row, fmt.Errorf("same content as question %s", dup.QuestionID))
+					continue
+				}
+				if err := Update(tx, existing, &updated, editedBy); err != nil {
+					return err
+				}
+				report.Updated++
+			}
+		}
+
+		if dryRun || len(report.Errors) > 0 {
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Export mengambil soal sesuai filter, terurut berdasarkan question_id
+func Export(db *gorm.DB, filter Filter) ([]Record, error) {
+	var questions []models.Question
+	if err := filter.Scope(db.Model(&models.Question{})).Order("question_id asc").Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	records := make([]Record, len(questions))
+	for i, q := range questions {
+		records[i] = RecordOf(q)
+	}
+	return records, nil
+}
+
+// csvColumns adalah kolom file CSV. question_data berisi objek JSON soal tanpa answer.
+var csvColumns = []string{
+	"question_id", "subtest_name", "question_data", "answer",
+	"difficulty", "status", "irt_discrimination", "irt_difficulty",
+}
+
+var requiredCSVColumns = []string{"question_id", "subtest_name", "question_data", "answer"}
+
+// Read membaca file import dalam format csv atau json
+func Read(r io.Reader, format string) ([]Row, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return ReadCSV(r)
+	case FormatJSON:
+		return ReadJSON(r)
+	default:
+		return nil, ErrInvalidFormat
+	}
+}
+
+// Write menulis records dalam format csv atau json
+func Write(w io.Writer, format string, records []Record) error {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return WriteCSV(w, records)
+	case FormatJSON:
+		return WriteJSON(w, records)
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+// ReadCSV membaca CSV dengan baris header. Urutan kolom bebas; kolom yang tidak dikenal atau
+// kolom wajib yang hilang membuat seluruh file ditolak, sedangkan nilai yang salah dicatat per baris.
+func ReadCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		// Excel menambahkan BOM di awal file CSV UTF-8
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown CSV column %q (allowed: %s)", name, strings.Join(csvColumns, ", "))
+		}
+		index[name] = i
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				rows = append(rows, Row{Number: line, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		if len(fields) != len(header) {
+			rows = append(rows, Row{Number: line, Err: fmt.Errorf("expected %d fields, got %d", len(header), len(fields))})
+			continue
+		}
+
+		get := func(name string) string {
+			if i, ok := index[name]; ok {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+		row := Row{Number: line, Record: Record{
+			QuestionID:   get("question_id"),
+			SubtestName:  get("subtest_name"),
+			QuestionData: json.RawMessage(get("question_data")),
+			Answer:       get("answer"),
+			Difficulty:   get("difficulty"),
+			Status:       get("status"),
+		}}
+		var problems []string
+		for name, dst := range map[string]**float64{
+			"irt_discrimination": &row.Record.IRTDiscrimination,
+			"irt_difficulty":     &row.Record.IRTDifficulty,
+		} {
+			if v := get(name); v != "" {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s must be a number", name))
+					continue
+				}
+				*dst = &f
+			}
+		}
+		if len(problems) > 0 {
+			row.Err = &testset.ValidationError{Problems: problems}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ReadJSON membaca array Record. Field yang tidak dikenal dianggap error pada baris tersebut.
+func ReadJSON(r io.Reader) ([]Row, error) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		return nil, fmt.Errorf("file must be a JSON array of questions: %w", err)
+	}
+
+	rows := make([]Row, len(elements))
+	for i, raw := range elements {
+		rows[i].Number = i + 1
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rows[i].Record); err != nil {
+			rows[i].Err = err
+		}
+	}
+	return rows, nil
+}
+
+// WriteCSV menulis records dengan header csvColumns, dapat dibaca kembali oleh ReadCSV
+func WriteCSV(w io.Writer, records []Record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, r := range records {
+		err := writer.Write([]string{
+			r.QuestionID, r.SubtestName, string(r.QuestionData), r.Answer,
+			r.Difficulty, r.Status, formatFloat(r.IRTDiscrimination), formatFloat(r.IRTDifficulty),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON menulis records sebagai array JSON, dapat dibaca kembali oleh ReadJSON
+func WriteJSON(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package questionbank
+
+import (
+	"Dysec/internal/models"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func float(v float64) *float64 { return &v }
+
+func sampleRecords() []Record {
+	return []Record{
+		{
+			QuestionID:        "q_1",
+			SubtestName:       "addition",
+			QuestionData:      json.RawMessage(`{"operands":[12,9],"operator":"+","text":"Berapa 12 + 9?, \"cepat\""}`),
+			Answer:            "21",
+			Difficulty:        models.DifficultyMedium,
+			Status:            models.QuestionStatusActive,
+			IRTDiscrimination: float(1.25),
+			IRTDifficulty:     float(-0.5),
+		},
+		{
+			QuestionID:   "q_2",
+			SubtestName:  "substitution",
+			QuestionData: json.RawMessage(`{"legend":[{"digit":1,"symbol":"▲"}],"symbol":"▲","text":"Simbol\nini?"}`),
+			Answer:       "1",
+			Status:       models.QuestionStatusRetired,
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, sampleRecords()); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			rows, err := Read(&buf, format)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			want := sampleRecords()
+			if len(rows) != len(want) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(want))
+			}
+			for i, row := range rows {
+				if row.Err != nil {
+					t.Fatalf("row %d: %v", row.Number, row.Err)
+				}
+				if !sameJSON(row.Record.QuestionData, want[i].QuestionData) {
+					t.Errorf("row %d: question_data = %s, want %s", row.Number, row.Record.QuestionData, want[i].QuestionData)
+				}
+				row.Record.QuestionData, want[i].QuestionData = nil, nil
+				if !reflect.DeepEqual(row.Record, want[i]) {
+					t.Errorf("row %d: got %+v, want %+v", row.Number, row.Record, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+		rowErr  string
+	}{
+		{"excel BOM", "\ufeffquestion_id,subtest_name,question_data,answer\nq_1,dot,\"{\"\"dot_count\"\":3}\",3\n", "", ""},
+		{"unknown column", "question_id,subtest_name,question_data,answer,extra\n", `unknown CSV column "extra"`, ""},
+		{"missing column", "question_id,subtest_name,question_data\n", `missing CSV column "answer"`, ""},
+		{"empty file", "", "could not read CSV header", ""},
+		{"wrong field count", "question_id,subtest_name,question_data,answer\nq_1,dot\n", "", "expected 4 fields, got 2"},
+		{"invalid number", "question_id,subtest_name,question_data,answer,irt_difficulty\nq_1,dot,{},3,sulit\n", "", "irt_difficulty must be a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadCSV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadCSV: %v", err)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+			row := rows[0]
+			if row.Number != 2 {
+				t.Errorf("row number = %d, want 2 (line in the file)", row.Number)
+			}
+			if tt.rowErr == "" {
+				if row.Err != nil || row.Record.QuestionID != "q_1" {
+					t.Fatalf("got %+v", row)
+				}
+				return
+			}
+			if row.Err == nil || !strings.Contains(row.Err.Error(), tt.rowErr) {
+				t.Fatalf("row error = %v, want %q", row.Err, tt.rowErr)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	record := Record{
+		SubtestName:  "addition",
+		QuestionData: json.RawMessage(`{"text":"Berapa 2 + 3?","operands":[2,3],"operator":"+"}`),
+		Answer:       "5",
+	}
+	tests := []struct {
+		name           string
+		question       models.Question
+		wantStatus     string
+		wantDifficulty string
+		wantB          float64
+	}{
+		{"new question", models.Question{QuestionID: "q_1"}, models.QuestionStatusActive, models.DifficultyMedium, 0},
+		{
+			"update keeps status, difficulty and IRT parameters",
+			models.Question{ID: 7, QuestionID: "q_1", Status: models.QuestionStatusRetired, Difficulty: models.DifficultyHard, IRTDifficulty: 1.7},
+			models.QuestionStatusRetired, models.DifficultyHard, 1.7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.question
+			if err := record.Apply(&q); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if q.Status != tt.wantStatus || q.Difficulty != tt.wantDifficulty || q.IRTDifficulty != tt.wantB {
+				t.Fatalf("got status %q, difficulty %q, b %v; want %q, %q, %v",
+					q.Status, q.Difficulty, q.IRTDifficulty, tt.wantStatus, tt.wantDifficulty, tt.wantB)
+			}
+		})
+	}
+}
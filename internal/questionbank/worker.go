@@ -0,0 +1,121 @@
+package questionbank
+
+import (
+	"Dysec/internal/ai"
+	"Dysec/internal/models"
+	"Dysec/internal/prompts"
+	"Dysec/internal/testset"
+	"Dysec/internal/usage"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Worker menjaga stok bank soal: untuk setiap subtes dan tingkat kesulitan,
+// jumlah soal dijaga minimal Target dengan membuat soal baru per batch
+type Worker struct {
+	DB        *gorm.DB
+	Generator ai.Generator
+	Prompts   *prompts.Renderer
+	Usage     *usage.Ledger
+	Target    int
+	BatchSize int
+	Interval  time.Duration
+}
+
+// NewWorkerFromConfig membaca question_bank.worker.*
+func NewWorkerFromConfig(db *gorm.DB, generator ai.Generator, prompts *prompts.Renderer, ledger *usage.Ledger) *Worker {
+	w := &Worker{
+		DB:        db,
+		Generator: generator,
+		Prompts:   prompts,
+		Usage:     ledger,
+		Target:    viper.GetInt("question_bank.worker.target_per_difficulty"),
+		BatchSize: viper.GetInt("question_bank.worker.batch_size"),
+		Interval:  viper.GetDuration("question_bank.worker.interval"),
+	}
+	if w.Target <= 0 {
+		w.Target = 20
+	}
+	if w.BatchSize <= 0 {
+		w.BatchSize = 5
+	}
+	if w.Interval <= 0 {
+		w.Interval = 5 * time.Minute
+	}
+	return w
+}
+
+// Start menjalankan worker di goroutine terpisah sampai ctx dibatalkan
+func (w *Worker) Start(ctx context.Context) {
+	go func() {
+		log.Printf("INFO: Question bank worker started (target %d per subtest and difficulty, every %s)", w.Target, w.Interval)
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+		for {
+			w.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("INFO: Question bank worker stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce mengisi ulang stok untuk semua tingkat kesulitan yang kurang, satu batch per tingkat
+func (w *Worker) RunOnce(ctx context.Context) {
+	for _, difficulty := range models.Difficulties {
+		if ctx.Err() != nil {
+			return
+		}
+
+		stock, err := Stock(w.DB, difficulty)
+		if err != nil {
+			log.Printf("ERROR: Question bank worker could not count stock: %v", err)
+			return
+		}
+
+		counts := map[string]int{}
+		missing := 0
+		for _, subtest := range testset.ItemSubtests {
+			if deficit := w.Target - stock[subtest]; deficit > 0 {
+				counts[subtest] = min(deficit, w.BatchSize)
+				missing += counts[subtest]
+			}
+		}
+		if missing == 0 {
+			continue
+		}
+
+		if err := w.generateBatch(ctx, difficulty, counts); err != nil {
+			if errors.Is(err, ai.ErrCircuitOpen) {
+				log.Println("INFO: Question bank worker paused, AI circuit breaker is open")
+				return
+			}
+			log.Printf("ERROR: Question bank worker failed to generate %s batch: %v", difficulty, err)
+		}
+	}
+}
+
+func (w *Worker) generateBatch(ctx context.Context, difficulty string, counts map[string]int) error {
+	prompt, _, err := w.Prompts.TestPrompt(counts, difficulty, "")
+	if err != nil {
+		return err
+	}
+	generated, usages, err := ai.GenerateTest(ctx, w.Generator, prompt, counts)
+	w.Usage.Record(nil, nil, usage.PurposeBankWorker, usages)
+	if err != nil {
+		return err
+	}
+
+	// Save mengganti ID lokal dari AI dengan ID server dan melewati soal yang isinya sudah ada
+	saved := Save(w.DB, generated, difficulty)
+	log.Printf("INFO: Question bank worker saved %d new %s questions", saved, difficulty)
+	return nil
+}
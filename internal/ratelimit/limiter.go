@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Policy adalah token bucket: Burst token maksimum, diisi ulang Rate token setiap Per
+type Policy struct {
+	Rate  float64       `mapstructure:"rate"`
+	Per   time.Duration `mapstructure:"per"`
+	Burst int           `mapstructure:"burst"`
+}
+
+func (p Policy) enabled() bool {
+	return p.Rate > 0 && p.Per > 0
+}
+
+// refillPerSecond adalah laju pengisian token per detik
+func (p Policy) refillPerSecond() float64 {
+	return p.Rate / p.Per.Seconds()
+}
+
+func (p Policy) capacity() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+	return math.Max(1, p.Rate)
+}
+
+// RoutePolicy mengatur limit satu route: default per user, override per role, dan limit per IP
+type RoutePolicy struct {
+	Policy `mapstructure:",squash"`
+	Roles  map[string]Policy `mapstructure:"roles"`
+	IP     Policy            `mapstructure:"ip"`
+}
+
+// Bucket adalah satu token bucket yang diperiksa untuk sebuah request
+type Bucket struct {
+	Key    string
+	Policy Policy
+}
+
+// Store menyimpan state bucket. Take mengambil satu token dari setiap bucket hanya jika semua
+// bucket masih punya token, sehingga bucket yang menolak tidak ikut menguras bucket lainnya.
+type Store interface {
+	Take(ctx context.Context, buckets []Bucket) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type Limiter struct {
+	store    Store
+	policies map[string]RoutePolicy
+}
+
+func NewLimiter(store Store, policies map[string]RoutePolicy) *Limiter {
+	return &Limiter{store: store, policies: policies}
+}
+
+// NewLimiterFromConfig membaca rate_limit.backend (memory|postgres) dan rate_limit.policies
+func NewLimiterFromConfig(db *gorm.DB) (*Limiter, error) {
+	var policies map[string]RoutePolicy
+	if err := viper.UnmarshalKey("rate_limit.policies", &policies); err != nil {
+		return nil, fmt.Errorf("invalid rate_limit.policies: %w", err)
+	}
+
+	var store Store
+	switch backend := strings.ToLower(viper.GetString("rate_limit.backend")); backend {
+	case "", "memory":
+		store = NewMemoryStore()
+	case "postgres":
+		store = NewPostgresStore(db)
+	default:
+		return nil, fmt.Errorf("unknown rate_limit.backend %q", backend)
+	}
+	return NewLimiter(store, policies), nil
+}
+
+// Allow memeriksa bucket user (sesuai role) dan bucket IP untuk sebuah route sekaligus:
+// token hanya diambil jika keduanya mengizinkan. Route tanpa policy selalu diizinkan.
+func (l *Limiter) Allow(ctx context.Context, route, userKey, role, ip string) (bool, time.Duration, error) {
+	rp, ok := l.policies[route]
+	if !ok {
+		return true, 0, nil
+	}
+
+	var buckets []Bucket
+	if userKey != "" {
+		p := rp.Policy
+		if rolePolicy, ok := rp.Roles[role]; ok {
+			p = rolePolicy
+		}
+		if p.enabled() {
+			buckets = append(buckets, Bucket{Key: route + ":user:" + userKey, Policy: p})
+		}
+	}
+	if rp.IP.enabled() && ip != "" {
+		buckets = append(buckets, Bucket{Key: route + ":ip:" + ip, Policy: rp.IP})
+	}
+	if len(buckets) == 0 {
+		return true, 0, nil
+	}
+	return l.store.Take(ctx, buckets)
+}
+
+// take menghitung ulang isi bucket setelah elapsed dan mencoba mengambil satu token
+func take(tokens float64, elapsed time.Duration, p Policy) (remaining float64, allowed bool, retryAfter time.Duration) {
+	state := []float64{tokens}
+	allowed, retryAfter = takeAll(state, []time.Duration{elapsed}, []Bucket{{Policy: p}})
+	return state[0], allowed, retryAfter
+}
+
+// takeAll mengisi ulang setiap bucket (tokens diperbarui di tempat) lalu mengambil satu token dari
+// semuanya jika tidak ada yang kosong. retryAfter adalah waktu tunggu terlama dari bucket yang menolak.
+// elapsed negatif (jam mundur) dianggap nol agar tidak mengurangi isi bucket.
+func takeAll(tokens []float64, elapsed []time.Duration, buckets []Bucket) (allowed bool, retryAfter time.Duration) {
+	for i, b := range buckets {
+		p := b.Policy
+		tokens[i] = math.Min(p.capacity(), tokens[i]+math.Max(0, elapsed[i].Seconds())*p.refillPerSecond())
+		if tokens[i] < 1 {
+			wait := (1 - tokens[i]) / p.refillPerSecond()
+			retryAfter = max(retryAfter, time.Duration(math.Ceil(wait*float64(time.Second))))
+		}
+	}
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+	for i := range tokens {
+		tokens[i]--
+	}
+	return true, 0
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	perMinute := Policy{Rate: 6, Per: time.Minute, Burst: 3} // satu token per 10 detik
+	tests := []struct {
+		name          string
+		tokens        float64
+		elapsed       time.Duration
+		policy        Policy
+		wantAllowed   bool
+		wantRemaining float64
+		wantRetry     time.Duration
+	}{
+		{"full bucket", 3, 0, perMinute, true, 2, 0},
+		{"last token", 1, 0, perMinute, true, 0, 0},
+		{"empty bucket", 0, 0, perMinute, false, 0, 10 * time.Second},
+		{"partly refilled", 0, 4 * time.Second, perMinute, false, 0.4, 6 * time.Second},
+		{"refilled one token", 0, 10 * time.Second, perMinute, true, 0, 0},
+		{"refill capped at burst", 0, time.Hour, perMinute, true, 2, 0},
+		{"clock moved backwards", 1, -time.Hour, perMinute, true, 0, 0},
+		{"burst defaults to rate", 0, time.Hour, Policy{Rate: 5, Per: time.Minute}, true, 4, 0},
+		{"burst at least one", 0, time.Hour, Policy{Rate: 0.5, Per: time.Minute}, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining, allowed, retry := take(tt.tokens, tt.elapsed, tt.policy)
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed = %v, want %v", allowed, tt.wantAllowed)
+			}
+			if math.Abs(remaining-tt.wantRemaining) > 1e-9 {
+				t.Errorf("remaining = %v, want %v", remaining, tt.wantRemaining)
+			}
+			if retry != tt.wantRetry {
+				t.Errorf("retryAfter = %v, want %v", retry, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	policies := map[string]RoutePolicy{
+		"tests_start": {
+			Policy: Policy{Rate: 1, Per: time.Hour, Burst: 1},
+			Roles:  map[string]Policy{"admin": {Rate: 2, Per: time.Hour, Burst: 2}},
+			IP:     Policy{Rate: 3, Per: time.Hour, Burst: 3},
+		},
+	}
+	type call struct {
+		route, user, role, ip string
+		want                  bool
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{"user bucket", []call{
+			{"tests_start", "1", "student", "10.0.0.1", true},
+			{"tests_start", "1", "student", "10.0.0.1", false},
+		}},
+		{"users have separate buckets", []call{
+			{"tests_start", "1", "student", "10.0.0.1", true},
+			{"tests_start", "2", "student", "10.0.0.2", true},
+		}},
+		{"role override", []call{
+			{"tests_start", "1", "admin", "10.0.0.1", true},
+			{"tests_start", "1", "admin", "10.0.0.1", true},
+			{"tests_start", "1", "admin", "10.0.0.1", false},
+		}},
+		{"ip bucket shared by users", []call{
+			{"tests_start", "1", "student", "10.0.0.1", true},
+			{"tests_start", "2", "student", "10.0.0.1", true},
+			{"tests_start", "3", "student", "10.0.0.1", true},
+			{"tests_start", "4", "student", "10.0.0.1", false},
+		}},
+		{"ip rejection does not drain the user bucket", []call{
+			{"tests_start", "1", "student", "10.0.0.1", true},
+			{"tests_start", "2", "student", "10.0.0.1", true},
+			{"tests_start", "3", "student", "10.0.0.1", true},
+			{"tests_start", "4", "student", "10.0.0.1", false},
+			{"tests_start", "4", "student", "10.0.0.2", true},
+		}},
+		{"user rejection does not drain the ip bucket", []call{
+			{"tests_start", "1", "student", "10.0.0.1", true},
+			{"tests_start", "1", "student", "10.0.0.1", false},
+			{"tests_start", "1", "student", "10.0.0.1", false},
+			{"tests_start", "2", "student", "10.0.0.1", true},
+			{"tests_start", "3", "student", "10.0.0.1", true},
+			{"tests_start", "4", "student", "10.0.0.1", false},
+		}},
+		{"anonymous uses only the ip bucket", []call{
+			{"tests_start", "", "", "10.0.0.1", true},
+			{"tests_start", "", "", "10.0.0.1", true},
+			{"tests_start", "", "", "10.0.0.1", true},
+			{"tests_start", "", "", "10.0.0.1", false},
+		}},
+		{"route without policy", []call{
+			{"unknown", "1", "student", "10.0.0.1", true},
+			{"unknown", "1", "student", "10.0.0.1", true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewLimiter(NewMemoryStore(), policies)
+			for i, c := range tt.calls {
+				allowed, retry, err := limiter.Allow(context.Background(), c.route, c.user, c.role, c.ip)
+				if err != nil {
+					t.Fatalf("call %d: %v", i, err)
+				}
+				if allowed != c.want {
+					t.Fatalf("call %d: allowed = %v, want %v", i, allowed, c.want)
+				}
+				if !allowed && retry <= 0 {
+					t.Fatalf("call %d: rejected without retryAfter", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memoryIdleTTL = time.Hour
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryStore menyimpan bucket di memori proses. Hanya akurat untuk satu replika.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{buckets: make(map[string]*memoryBucket)}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemoryStore) Take(ctx context.Context, buckets []Bucket) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	tokens := make([]float64, len(buckets))
+	elapsed := make([]time.Duration, len(buckets))
+	for i, bucket := range buckets {
+		b, ok := s.buckets[bucket.Key]
+		if !ok {
+			b = &memoryBucket{tokens: bucket.Policy.capacity(), updatedAt: now}
+			s.buckets[bucket.Key] = b
+		}
+		tokens[i], elapsed[i] = b.tokens, now.Sub(b.updatedAt)
+	}
+
+	allowed, retryAfter := takeAll(tokens, elapsed, buckets)
+	for i, bucket := range buckets {
+		b := s.buckets[bucket.Key]
+		b.tokens = tokens[i]
+		b.updatedAt = now
+	}
+	return allowed, retryAfter, nil
+}
+
+// cleanup membuang bucket yang lama tidak dipakai agar map tidak terus membesar
+func (s *MemoryStore) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		for key, b := range s.buckets {
+			if time.Since(b.updatedAt) > memoryIdleTTL {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
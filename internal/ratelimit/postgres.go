@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"Dysec/internal/models"
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const postgresIdleTTL = 24 * time.Hour
+
+// PostgresStore menyimpan bucket di tabel rate_limit_buckets agar limit berlaku di semua replika
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	s := &PostgresStore{db: db}
+	go s.cleanup()
+	return s
+}
+
+func (s *PostgresStore) Take(ctx context.Context, buckets []Bucket) (bool, time.Duration, error) {
+	var allowed bool
+	var retryAfter time.Duration
+
+	// Baris dikunci berurutan menurut key agar dua request yang berbagi bucket tidak saling deadlock
+	buckets = slices.Clone(buckets)
+	slices.SortFunc(buckets, func(a, b Bucket) int { return strings.Compare(a.Key, b.Key) })
+
+	// Waktu diambil dari clock_timestamp() database, bukan time.Now() replika, agar selisih jam
+	// antar replika tidak merusak state bucket bersama
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tokens := make([]float64, len(buckets))
+		elapsed := make([]time.Duration, len(buckets))
+		for i, b := range buckets {
+			err := tx.Exec("INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES (?, ?, clock_timestamp()) ON CONFLICT (key) DO NOTHING",
+				b.Key, b.Policy.capacity()).Error
+			if err != nil {
+				return err
+			}
+
+			// Kunci baris agar replika lain menunggu sampai bucket ini selesai diperbarui
+			var bucket struct {
+				Tokens         float64
+				ElapsedSeconds float64
+			}
+			err = tx.Raw("SELECT tokens, EXTRACT(EPOCH FROM clock_timestamp() - updated_at)::float8 AS elapsed_seconds FROM rate_limit_buckets WHERE key = ? FOR UPDATE",
+				b.Key).Scan(&bucket).Error
+			if err != nil {
+				return err
+			}
+			tokens[i], elapsed[i] = bucket.Tokens, time.Duration(bucket.ElapsedSeconds*float64(time.Second))
+		}
+
+		allowed, retryAfter = takeAll(tokens, elapsed, buckets)
+		for i, b := range buckets {
+			err := tx.Model(&models.RateLimitBucket{}).Where("key = ?", b.Key).Updates(map[string]interface{}{
+				"tokens":     tokens[i],
+				"updated_at": gorm.Expr("clock_timestamp()"),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return allowed, retryAfter, err
+}
+
+func (s *PostgresStore) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := s.db.Where("updated_at < clock_timestamp() - make_interval(secs => ?)", postgresIdleTTL.Seconds()).
+			Delete(&models.RateLimitBucket{}).Error
+		if err != nil {
+			log.Printf("ERROR: Could not clean up rate limit buckets: %v", err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package scoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxResponseBytes = 1 << 20
+
+// HTTPScorer memanggil layanan klasifikasi ML lewat POST JSON
+type HTTPScorer struct {
+	URL        string
+	AuthHeader string
+	Token      string
+	Timeout    time.Duration
+	client     *http.Client
+}
+
+// NewHTTPScorer membuat scorer HTTP. authHeader kosong berarti Authorization dengan skema Bearer.
+func NewHTTPScorer(url, authHeader, token string, timeout time.Duration) (*HTTPScorer, error) {
+	if url == "" {
+		return nil, fmt.Errorf("scoring.url is required for the http scoring provider")
+	}
+	if authHeader == "" {
+		authHeader = "Authorization"
+	}
+
+	log.Printf("Scoring client initialized (%s)", url)
+	return &HTTPScorer{URL: url, AuthHeader: authHeader, Token: token, Timeout: timeout, client: &http.Client{}}, nil
+}
+
+// StatusError adalah respons non-200 dari layanan klasifikasi
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("scoring service returned status %d: %s", e.StatusCode, e.Body)
+}
+
+func (s *HTTPScorer) Score(ctx context.Context, features Features) (*Result, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	body, err := json.Marshal(features)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		if strings.EqualFold(s.AuthHeader, "Authorization") {
+			req.Header.Set(s.AuthHeader, "Bearer "+s.Token)
+		} else {
+			req.Header.Set(s.AuthHeader, s.Token)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal memanggil layanan scoring: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal membaca respons layanan scoring: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(raw))}
+	}
+
+	var result Result
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResult, err)
+	}
+	if err := result.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return &result, raw, nil
+}
@@ -0,0 +1,87 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Features adalah input layanan klasifikasi: usia, median waktu reaksi (rt) dan akurasi (acc) per subtes
+type Features struct {
+	Age       int     `json:"age"`
+	Srt       float64 `json:"srt"`
+	DotRt     float64 `json:"dot_rt"`
+	DotAcc    float64 `json:"dot_acc"`
+	StroopRt  float64 `json:"stroop_rt"`
+	StroopAcc float64 `json:"stroop_acc"`
+	AddRt     float64 `json:"add_rt"`
+	AddAcc    float64 `json:"add_acc"`
+	MultRt    float64 `json:"mult_rt"`
+	MultAcc   float64 `json:"mult_acc"`
+	SubsRt    float64 `json:"subs_rt"`
+	SubsAcc   float64 `json:"subs_acc"`
+}
+
+// Labels memetakan kode diagnosis ("0", "1", "2") ke nama yang bisa dibaca
+type Labels struct {
+	Diagnosis map[string]string `json:"diagnosis"`
+}
+
+// Result adalah respons layanan klasifikasi
+type Result struct {
+	Diagnosis     int                `json:"diagnosis"`
+	Label         Labels             `json:"label"`
+	Probabilities map[string]float64 `json:"probabilitas"`
+	Scores        []float64          `json:"skor"`
+	ModelVersion  string             `json:"model_version,omitempty"`
+}
+
+var ErrInvalidResult = errors.New("invalid scoring result")
+
+// Validate memastikan diagnosis punya probabilitas dan semua probabilitas berada di [0, 1]
+func (r *Result) Validate() error {
+	if _, ok := r.Probabilities[strconv.Itoa(r.Diagnosis)]; !ok {
+		return fmt.Errorf("%w: no probability for diagnosis %d", ErrInvalidResult, r.Diagnosis)
+	}
+	for class, p := range r.Probabilities {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%w: probability %q out of range: %v", ErrInvalidResult, class, p)
+		}
+	}
+	return nil
+}
+
+// FinalScore adalah probabilitas kelas yang didiagnosis
+func (r *Result) FinalScore() float64 {
+	return r.Probabilities[strconv.Itoa(r.Diagnosis)]
+}
+
+// Scorer mengklasifikasikan hasil tes. raw adalah body respons asli untuk disimpan di AiScore.RawResponse.
+type Scorer interface {
+	Score(ctx context.Context, features Features) (result *Result, raw []byte, err error)
+}
+
+// NewScorerFromConfig memilih scorer berdasarkan scoring.provider: http (default) atau stub (lokal, tanpa jaringan)
+func NewScorerFromConfig() (Scorer, error) {
+	switch provider := strings.ToLower(viper.GetString("scoring.provider")); provider {
+	case "", "http":
+		token := viper.GetString("SCORING_API_TOKEN") // Coba dari env var
+		if token == "" {
+			token = viper.GetString("scoring.auth.token")
+		}
+		timeout := viper.GetDuration("scoring.timeout")
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		return NewHTTPScorer(viper.GetString("scoring.url"), viper.GetString("scoring.auth.header"), token, timeout)
+	case "stub":
+		return StubScorer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown scoring.provider %q", provider)
+	}
+}
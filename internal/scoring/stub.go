@@ -0,0 +1,94 @@
+package scoring
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"math"
+	"net/http"
+)
+
+var stubLabels = Labels{Diagnosis: map[string]string{
+	"0": "Normal",
+	"1": "Diskalkulia",
+	"2": "Keterampilan Aritmatika yang Buruk",
+}}
+
+// StubScore adalah klasifikasi tiruan yang deterministik untuk development dan test.
+// Diagnosis hanya ditentukan dari rata-rata akurasi; hasilnya BUKAN diagnosis klinis.
+func StubScore(f Features) Result {
+	accuracies := []float64{f.DotAcc, f.StroopAcc, f.AddAcc, f.MultAcc, f.SubsAcc}
+
+	mean := 0.0
+	for _, acc := range accuracies {
+		mean += acc
+	}
+	mean /= float64(len(accuracies))
+
+	probabilities := map[string]float64{
+		"0": round2(mean * mean),
+		"1": round2((1 - mean) * (1 - mean)),
+	}
+	probabilities["2"] = round2(1 - probabilities["0"] - probabilities["1"])
+
+	diagnosis := 0
+	switch {
+	case mean < 0.5:
+		diagnosis = 1
+	case mean < 0.75:
+		diagnosis = 2
+	}
+
+	scores := make([]float64, len(accuracies))
+	for i, acc := range accuracies {
+		scores[i] = round2((1 - acc) * 5)
+	}
+
+	return Result{
+		Diagnosis:     diagnosis,
+		Label:         stubLabels,
+		Probabilities: probabilities,
+		Scores:        scores,
+		ModelVersion:  "stub",
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// StubScorer menjalankan StubScore di dalam proses (scoring.provider = stub)
+type StubScorer struct{}
+
+func (StubScorer) Score(_ context.Context, features Features) (*Result, []byte, error) {
+	result := StubScore(features)
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &result, raw, nil
+}
+
+// StubHandler melayani POST dengan body Features dan membalas StubScore, meniru layanan ML.
+// token kosong berarti tanpa autentikasi; selain itu wajib header Authorization: Bearer <token>.
+func StubHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var features Features
+		if err := json.NewDecoder(r.Body).Decode(&features); err != nil {
+			http.Error(w, "invalid features: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StubScore(features))
+	})
+}
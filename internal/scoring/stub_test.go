@@ -0,0 +1,105 @@
+package scoring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStubHandler(t *testing.T) {
+	const token = "rahasia"
+	features := `{"age": 9, "dot_acc": 1, "stroop_acc": 1, "add_acc": 1, "mult_acc": 1, "subs_acc": 1}`
+	tests := []struct {
+		name      string
+		method    string
+		auth      string
+		body      string
+		status    int
+		diagnosis int
+	}{
+		{"valid request", http.MethodPost, "Bearer " + token, features, http.StatusOK, 0},
+		{"low accuracy", http.MethodPost, "Bearer " + token, `{"dot_acc": 0.2, "add_acc": 0.1}`, http.StatusOK, 1},
+		{"missing token", http.MethodPost, "", features, http.StatusUnauthorized, 0},
+		{"wrong token", http.MethodPost, "Bearer salah", features, http.StatusUnauthorized, 0},
+		{"wrong method", http.MethodGet, "Bearer " + token, "", http.StatusMethodNotAllowed, 0},
+		{"invalid body", http.MethodPost, "Bearer " + token, `{"age": "sembilan"}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/predict", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			StubHandler(token).ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var result Result
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if err := result.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if result.Diagnosis != tt.diagnosis {
+				t.Fatalf("diagnosis = %d, want %d", result.Diagnosis, tt.diagnosis)
+			}
+		})
+	}
+}
+
+func TestStubHandlerWithoutToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/predict", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	StubHandler("").ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+}
+
+func TestHTTPScorerAgainstStub(t *testing.T) {
+	server := httptest.NewServer(StubHandler("rahasia"))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		token string
+		check func(t *testing.T, result *Result, err error)
+	}{
+		{"authorized", "rahasia", func(t *testing.T, result *Result, err error) {
+			if err != nil {
+				t.Fatalf("Score: %v", err)
+			}
+			if result.Diagnosis != 2 || result.ModelVersion != "stub" {
+				t.Fatalf("got diagnosis %d from %q", result.Diagnosis, result.ModelVersion)
+			}
+		}},
+		{"unauthorized", "salah", func(t *testing.T, result *Result, err error) {
+			var serr *StatusError
+			if !errors.As(err, &serr) || serr.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("got %v, want StatusError 401", err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer, err := NewHTTPScorer(server.URL, "", tt.token, 5*time.Second)
+			if err != nil {
+				t.Fatalf("NewHTTPScorer: %v", err)
+			}
+			features := Features{DotAcc: 0.7, StroopAcc: 0.7, AddAcc: 0.7, MultAcc: 0.7, SubsAcc: 0.7}
+			result, _, err := scorer.Score(context.Background(), features)
+			tt.check(t, result, err)
+		})
+	}
+}
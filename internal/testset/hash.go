@@ -0,0 +1,48 @@
+package testset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// ContentHash adalah sidik isi soal untuk deduplikasi bank soal. question_id, answer, dan type tidak
+// ikut dihitung, teks dinormalisasi (huruf kecil, spasi dirapikan), dan urutan key JSON tidak berpengaruh,
+// sehingga soal yang sama dari batch AI berbeda menghasilkan hash yang sama.
+func ContentHash(subtest string, data json.RawMessage) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, "question_id")
+	delete(fields, "answer")
+	delete(fields, "type")
+
+	// json.Marshal mengurutkan key map, jadi hasilnya kanonik
+	canonical, err := json.Marshal(normalize(fields))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(subtest + "\n" + string(canonical)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return strings.Join(strings.Fields(strings.ToLower(v)), " ")
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,46 @@
+package testset
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContentHash(t *testing.T) {
+	base := `{"question_id": "add_1", "type": "addition", "text": "Berapa 12 + 9?", "operands": [12, 9], "operator": "+"}`
+	tests := []struct {
+		name    string
+		subtest string
+		data    string
+		same    bool
+	}{
+		{"identical", SubtestAddition, base, true},
+		{"different id, answer and type", SubtestAddition, `{"question_id": "q_9", "type": "x", "answer": "21", "text": "Berapa 12 + 9?", "operands": [12, 9], "operator": "+"}`, true},
+		{"key order", SubtestAddition, `{"operator": "+", "operands": [12, 9], "text": "Berapa 12 + 9?"}`, true},
+		{"case and whitespace", SubtestAddition, `{"text": "  BERAPA  12 +  9?", "operands": [12, 9], "operator": "+"}`, true},
+		{"different operands", SubtestAddition, `{"text": "Berapa 12 + 9?", "operands": [9, 12], "operator": "+"}`, false},
+		{"different text", SubtestAddition, `{"text": "Berapa 12 + 8?", "operands": [12, 9], "operator": "+"}`, false},
+		{"different subtest", SubtestMultiplication, base, false},
+	}
+
+	want, err := ContentHash(SubtestAddition, json.RawMessage(base))
+	if err != nil {
+		t.Fatalf("ContentHash: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ContentHash(tt.subtest, json.RawMessage(tt.data))
+			if err != nil {
+				t.Fatalf("ContentHash: %v", err)
+			}
+			if (got == want) != tt.same {
+				t.Fatalf("hash equal = %v, want %v", got == want, tt.same)
+			}
+		})
+	}
+}
+
+func TestContentHashInvalidJSON(t *testing.T) {
+	if _, err := ContentHash(SubtestDot, json.RawMessage(`[1`)); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
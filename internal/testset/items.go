@@ -0,0 +1,152 @@
+package testset
+
+import (
+	"encoding/json"
+)
+
+// Item adalah satu soal terstruktur hasil generator. Answer hanya disimpan di kunci jawaban.
+type Item interface {
+	ID() string
+	AnswerValue() string
+}
+
+// DotItem: anak menghitung jumlah titik yang ditampilkan
+type DotItem struct {
+	QuestionID string `json:"question_id"`
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	DotCount   int    `json:"dot_count"`
+	Answer     string `json:"answer"`
+}
+
+// StroopItem: numerical Stroop, memilih angka dengan nilai lebih besar meski ukuran fisiknya berbeda
+type StroopItem struct {
+	QuestionID string `json:"question_id"`
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	Left       int    `json:"left"`
+	Right      int    `json:"right"`
+	LeftSize   int    `json:"left_size"`
+	RightSize  int    `json:"right_size"`
+	Answer     string `json:"answer"`
+}
+
+// ArithmeticItem dipakai subtes addition ("+") dan multiplication ("x")
+type ArithmeticItem struct {
+	QuestionID string `json:"question_id"`
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	Operands   []int  `json:"operands"`
+	Operator   string `json:"operator"`
+	Answer     string `json:"answer"`
+}
+
+type SymbolDigit struct {
+	Symbol string `json:"symbol"`
+	Digit  int    `json:"digit"`
+}
+
+// SubstitutionItem: anak mencari angka pasangan Symbol berdasarkan Legend
+type SubstitutionItem struct {
+	QuestionID string        `json:"question_id"`
+	Type       string        `json:"type"`
+	Text       string        `json:"text"`
+	Legend     []SymbolDigit `json:"legend"`
+	Symbol     string        `json:"symbol"`
+	Answer     string        `json:"answer"`
+}
+
+func (i DotItem) ID() string          { return i.QuestionID }
+func (i DotItem) AnswerValue() string { return i.Answer }
+
+func (i StroopItem) ID() string          { return i.QuestionID }
+func (i StroopItem) AnswerValue() string { return i.Answer }
+
+func (i ArithmeticItem) ID() string          { return i.QuestionID }
+func (i ArithmeticItem) AnswerValue() string { return i.Answer }
+
+func (i SubstitutionItem) ID() string          { return i.QuestionID }
+func (i SubstitutionItem) AnswerValue() string { return i.Answer }
+
+// Generated adalah output terstruktur generator (AI) untuk semua subtes yang memiliki soal
+type Generated struct {
+	Dot            []DotItem          `json:"dot"`
+	Stroop         []StroopItem       `json:"stroop"`
+	Addition       []ArithmeticItem   `json:"addition"`
+	Multiplication []ArithmeticItem   `json:"multiplication"`
+	Substitution   []SubstitutionItem `json:"substitution"`
+}
+
+// Items mengembalikan soal per subtes dalam bentuk Item
+func (g Generated) Items() map[string][]Item {
+	items := map[string][]Item{}
+	for _, i := range g.Dot {
+		items[SubtestDot] = append(items[SubtestDot], i)
+	}
+	for _, i := range g.Stroop {
+		items[SubtestStroop] = append(items[SubtestStroop], i)
+	}
+	for _, i := range g.Addition {
+		items[SubtestAddition] = append(items[SubtestAddition], i)
+	}
+	for _, i := range g.Multiplication {
+		items[SubtestMultiplication] = append(items[SubtestMultiplication], i)
+	}
+	for _, i := range g.Substitution {
+		items[SubtestSubstitution] = append(items[SubtestSubstitution], i)
+	}
+	return items
+}
+
+// PublicJSON menghasilkan JSON soal seperti yang dilihat klien, tanpa field answer
+func PublicJSON(item Item) (json.RawMessage, error) {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "answer")
+	return json.Marshal(fields)
+}
+
+// TestSet mengubah output generator ke format sesi (questions + answer_key)
+func (g Generated) TestSet() (TestSet, error) {
+	set := NewTestSet()
+	for subtest, items := range g.Items() {
+		for _, item := range items {
+			question, err := PublicJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			set.Add(subtest, item.ID(), question, item.AnswerValue())
+		}
+	}
+	return set, nil
+}
+
+// SetIDs mengganti question_id sesuai ids (ID lama ke ID baru). ID yang tidak ada di ids tidak berubah.
+func (g *Generated) SetIDs(ids map[string]string) {
+	rename := func(id *string) {
+		if newID, ok := ids[*id]; ok {
+			*id = newID
+		}
+	}
+	for i := range g.Dot {
+		rename(&g.Dot[i].QuestionID)
+	}
+	for i := range g.Stroop {
+		rename(&g.Stroop[i].QuestionID)
+	}
+	for i := range g.Addition {
+		rename(&g.Addition[i].QuestionID)
+	}
+	for i := range g.Multiplication {
+		rename(&g.Multiplication[i].QuestionID)
+	}
+	for i := range g.Substitution {
+		rename(&g.Substitution[i].QuestionID)
+	}
+}
@@ -0,0 +1,71 @@
+package testset
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+const (
+	SubtestSimpleReactionTime = "simple_reaction_time"
+	SubtestDot                = "dot"
+	SubtestStroop             = "stroop"
+	SubtestAddition           = "addition"
+	SubtestMultiplication     = "multiplication"
+	SubtestSubstitution       = "substitution"
+)
+
+// ItemSubtests adalah subtes yang memiliki soal (simple_reaction_time tidak punya soal)
+var ItemSubtests = []string{SubtestDot, SubtestStroop, SubtestAddition, SubtestMultiplication, SubtestSubstitution}
+
+// DefaultCounts adalah jumlah soal per subtes untuk satu sesi tes
+var DefaultCounts = map[string]int{
+	SubtestDot:            2,
+	SubtestStroop:         2,
+	SubtestAddition:       2,
+	SubtestMultiplication: 2,
+	SubtestSubstitution:   2,
+}
+
+// Subtest adalah format yang dikirim ke klien dan disimpan di UserTest.AnswerKey
+type Subtest struct {
+	Questions []json.RawMessage `json:"questions"`
+	AnswerKey map[string]string `json:"answer_key"`
+}
+
+// TestSet adalah kumpulan subtes untuk satu sesi, dengan key nama subtes
+type TestSet map[string]Subtest
+
+// NewTestSet membuat TestSet kosong yang sudah berisi semua subtes
+func NewTestSet() TestSet {
+	set := TestSet{SubtestSimpleReactionTime: {Questions: []json.RawMessage{}, AnswerKey: map[string]string{}}}
+	for _, name := range ItemSubtests {
+		set[name] = Subtest{Questions: []json.RawMessage{}, AnswerKey: map[string]string{}}
+	}
+	return set
+}
+
+// Add menambahkan satu soal (tanpa kunci jawaban) beserta jawabannya ke subtes
+func (t TestSet) Add(subtest, questionID string, question json.RawMessage, answer string) {
+	st, ok := t[subtest]
+	if !ok {
+		st = Subtest{Questions: []json.RawMessage{}, AnswerKey: map[string]string{}}
+	}
+	st.Questions = append(st.Questions, question)
+	st.AnswerKey[questionID] = answer
+	t[subtest] = st
+}
+
+// Count mengembalikan jumlah soal di sebuah subtes
+func (t TestSet) Count(subtest string) int {
+	return len(t[subtest].Questions)
+}
+
+// Names mengembalikan nama subtes yang terurut, agar iterasi deterministik
+func (t TestSet) Names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,164 @@
+package testset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidationError mengumpulkan semua masalah pada output generator, agar bisa dikirim balik ke AI untuk diperbaiki
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid generated test: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// Parse men-decode output generator secara ketat: field yang tidak dikenal dianggap error
+func Parse(raw string) (*Generated, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(strings.TrimSpace(raw))))
+	dec.DisallowUnknownFields()
+
+	var g Generated
+	if err := dec.Decode(&g); err != nil {
+		return nil, &ValidationError{Problems: []string{"output is not valid JSON for the schema: " + err.Error()}}
+	}
+	if dec.More() {
+		return nil, &ValidationError{Problems: []string{"output contains data after the JSON object"}}
+	}
+	return &g, nil
+}
+
+// Validate memeriksa jumlah soal, keunikan question_id, kelengkapan field, dan format jawaban
+func (g Generated) Validate(counts map[string]int) error {
+	verr := &ValidationError{}
+	seen := map[string]bool{}
+	items := g.Items()
+
+	for _, subtest := range ItemSubtests {
+		if want := counts[subtest]; len(items[subtest]) != want {
+			verr.add("%s must have exactly %d questions, got %d", subtest, want, len(items[subtest]))
+		}
+		for _, item := range items[subtest] {
+			id := item.ID()
+			if id == "" {
+				verr.add("%s has a question without question_id", subtest)
+				continue
+			}
+			if seen[id] {
+				verr.add("question_id %q is used more than once", id)
+			}
+			seen[id] = true
+			if item.AnswerValue() == "" {
+				verr.add("%s: answer is empty", id)
+			}
+		}
+	}
+
+	for _, i := range g.Dot {
+		if i.DotCount <= 0 {
+			verr.add("%s: dot_count must be positive", i.QuestionID)
+		}
+		requireInt(verr, i.QuestionID, i.Answer)
+	}
+	for _, i := range g.Stroop {
+		if i.Left == i.Right {
+			verr.add("%s: left and right must differ", i.QuestionID)
+		}
+		if i.Answer != strconv.Itoa(i.Left) && i.Answer != strconv.Itoa(i.Right) {
+			verr.add("%s: answer must be either left or right", i.QuestionID)
+		}
+	}
+	arithmetic := []struct {
+		operator string
+		items    []ArithmeticItem
+	}{{"+", g.Addition}, {"x", g.Multiplication}}
+	for _, group := range arithmetic {
+		operator := group.operator
+		for _, i := range group.items {
+			if i.Text == "" {
+				verr.add("%s: text is empty", i.QuestionID)
+			}
+			if len(i.Operands) < 2 {
+				verr.add("%s: operands must contain at least two numbers", i.QuestionID)
+			}
+			if i.Operator != operator {
+				verr.add("%s: operator must be %q", i.QuestionID, operator)
+			}
+			requireInt(verr, i.QuestionID, i.Answer)
+		}
+	}
+	for _, i := range g.Substitution {
+		if len(i.Legend) == 0 || i.Symbol == "" {
+			verr.add("%s: legend and symbol are required", i.QuestionID)
+		}
+		requireInt(verr, i.QuestionID, i.Answer)
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func requireInt(verr *ValidationError, id, answer string) {
+	if _, err := strconv.Atoi(answer); answer != "" && err != nil {
+		verr.add("%s: answer must be a whole number", id)
+	}
+}
+
+// ParseItem membangun satu soal dari data yang disimpan di bank (QuestionData tanpa answer) ditambah
+// kunci jawabannya, lalu menjalankan pemeriksaan yang sama dengan output generator. Kunci jawaban yang
+// tidak sesuai isi soal dianggap error, bukan diperbaiki diam-diam.
+func ParseItem(subtest, questionID string, data json.RawMessage, answer string) (Item, error) {
+	if !isItemSubtest(subtest) {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("unknown subtest %q", subtest)}}
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, &ValidationError{Problems: []string{"question_data must be a JSON object"}}
+	}
+	fields["question_id"] = questionID
+	fields["answer"] = answer
+
+	raw, err := json.Marshal(map[string]interface{}{subtest: []interface{}{fields}})
+	if err != nil {
+		return nil, err
+	}
+	g, err := Parse(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	if err := g.Validate(map[string]int{subtest: 1}); err != nil {
+		return nil, err
+	}
+	fixes, err := g.Verify()
+	if err != nil {
+		return nil, err
+	}
+	if len(fixes) > 0 {
+		verr := &ValidationError{}
+		for _, f := range fixes {
+			verr.add("answer key does not match the question (%s)", f)
+		}
+		return nil, verr
+	}
+	return g.Items()[subtest][0], nil
+}
+
+func isItemSubtest(subtest string) bool {
+	for _, s := range ItemSubtests {
+		if s == subtest {
+			return true
+		}
+	}
+	return false
+}
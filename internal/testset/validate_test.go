@@ -0,0 +1,136 @@
+package testset
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// validGenerated adalah satu soal benar per subtes
+func validGenerated() Generated {
+	return Generated{
+		Dot:    []DotItem{{QuestionID: "dot_1", Text: "Berapa titik?", DotCount: 5, Answer: "5"}},
+		Stroop: []StroopItem{{QuestionID: "stroop_1", Text: "Mana lebih besar?", Left: 3, Right: 8, LeftSize: 40, RightSize: 20, Answer: "8"}},
+		Addition: []ArithmeticItem{
+			{QuestionID: "add_1", Text: "12 + 9 = ?", Operands: []int{12, 9}, Operator: "+", Answer: "21"},
+		},
+		Multiplication: []ArithmeticItem{
+			{QuestionID: "mult_1", Text: "3 x 4 = ?", Operands: []int{3, 4}, Operator: "x", Answer: "12"},
+		},
+		Substitution: []SubstitutionItem{{
+			QuestionID: "subs_1",
+			Text:       "Angka untuk simbol ini?",
+			Legend:     []SymbolDigit{{Symbol: "▲", Digit: 1}, {Symbol: "●", Digit: 2}},
+			Symbol:     "●",
+			Answer:     "2",
+		}},
+	}
+}
+
+var oneEach = map[string]int{
+	SubtestDot: 1, SubtestStroop: 1, SubtestAddition: 1, SubtestMultiplication: 1, SubtestSubstitution: 1,
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(g *Generated)
+		counts  map[string]int
+		problem string
+	}{
+		{"valid", func(g *Generated) {}, oneEach, ""},
+		{"wrong count", func(g *Generated) {}, map[string]int{SubtestDot: 2}, "dot must have exactly 2 questions, got 1"},
+		{"missing id", func(g *Generated) { g.Dot[0].QuestionID = "" }, oneEach, "dot has a question without question_id"},
+		{"duplicate id", func(g *Generated) { g.Stroop[0].QuestionID = "dot_1" }, oneEach, `question_id "dot_1" is used more than once`},
+		{"empty answer", func(g *Generated) { g.Dot[0].Answer = "" }, oneEach, "dot_1: answer is empty"},
+		{"non-positive dot count", func(g *Generated) { g.Dot[0].DotCount = 0 }, oneEach, "dot_1: dot_count must be positive"},
+		{"stroop sides equal", func(g *Generated) { g.Stroop[0].Right = 3 }, oneEach, "stroop_1: left and right must differ"},
+		{"stroop answer not a side", func(g *Generated) { g.Stroop[0].Answer = "5" }, oneEach, "stroop_1: answer must be either left or right"},
+		{"wrong operator", func(g *Generated) { g.Addition[0].Operator = "x" }, oneEach, `add_1: operator must be "+"`},
+		{"one operand", func(g *Generated) { g.Multiplication[0].Operands = []int{3} }, oneEach, "mult_1: operands must contain at least two numbers"},
+		{"non-numeric answer", func(g *Generated) { g.Addition[0].Answer = "dua puluh" }, oneEach, "add_1: answer must be a whole number"},
+		{"empty legend", func(g *Generated) { g.Substitution[0].Legend = nil }, oneEach, "subs_1: legend and symbol are required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := validGenerated()
+			tt.mutate(&g)
+			err := g.Validate(tt.counts)
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got %v, want *ValidationError", err)
+			}
+			if !containsProblem(verr.Problems, tt.problem) {
+				t.Fatalf("problems %q do not include %q", verr.Problems, tt.problem)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"unknown field", `{"dot": [{"question_id": "dot_1", "dots": 3}]}`},
+		{"trailing data", `{"dot": []} {"dot": []}`},
+		{"not json", `soal: 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verr *ValidationError
+			if _, err := Parse(tt.raw); !errors.As(err, &verr) {
+				t.Fatalf("got %v, want *ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestParseItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		subtest string
+		data    string
+		answer  string
+		problem string
+	}{
+		{"valid addition", SubtestAddition, `{"text": "7 + 5 = ?", "operands": [7, 5], "operator": "+"}`, "12", ""},
+		{"wrong answer key", SubtestAddition, `{"text": "7 + 5 = ?", "operands": [7, 5], "operator": "+"}`, "13", "answer key does not match the question"},
+		{"operands differ from text", SubtestAddition, `{"text": "7 + 5 = ?", "operands": [7, 6], "operator": "+"}`, "13", "do not match the text"},
+		{"unknown subtest", "reading", `{"text": "?"}`, "1", `unknown subtest "reading"`},
+		{"data not an object", SubtestDot, `[1, 2]`, "1", "question_data must be a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := ParseItem(tt.subtest, "q_1", json.RawMessage(tt.data), tt.answer)
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if item.ID() != "q_1" || item.AnswerValue() != tt.answer {
+					t.Fatalf("got item %s with answer %q", item.ID(), item.AnswerValue())
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("got %v, want error containing %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func containsProblem(problems []string, want string) bool {
+	for _, p := range problems {
+		if p == want {
+			return true
+		}
+	}
+	return false
+}
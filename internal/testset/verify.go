@@ -0,0 +1,152 @@
+package testset
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// expressionPattern menangkap ekspresi di teks soal, mis. "12 + 9" atau "3 x 4 x 2"
+var expressionPattern = regexp.MustCompile(`\d+(?:\s*[+xX×*]\s*\d+)+`)
+
+var numberPattern = regexp.MustCompile(`\d+`)
+
+// Verify menghitung ulang kunci jawaban dari isi soal. Kunci yang salah tetapi bisa dihitung pasti
+// (mis. "12 + 9" dengan answer "20") diperbaiki di tempat dan dicatat di fixes. Soal yang isinya
+// tidak konsisten sehingga jawaban benarnya tidak bisa dipastikan dikembalikan sebagai ValidationError.
+func (g *Generated) Verify() (fixes []string, err error) {
+	verr := &ValidationError{}
+	fix := func(id string, answer *string, correct int) {
+		if want := strconv.Itoa(correct); *answer != want {
+			fixes = append(fixes, fmt.Sprintf("%s: answer %q corrected to %q", id, *answer, want))
+			*answer = want
+		}
+	}
+
+	for i := range g.Dot {
+		item := &g.Dot[i]
+		fix(item.QuestionID, &item.Answer, item.DotCount)
+	}
+
+	for i := range g.Stroop {
+		item := &g.Stroop[i]
+		fix(item.QuestionID, &item.Answer, max(item.Left, item.Right))
+	}
+
+	arithmetic := []struct {
+		operator string
+		items    []ArithmeticItem
+	}{{"+", g.Addition}, {"x", g.Multiplication}}
+	for _, group := range arithmetic {
+		for i := range group.items {
+			item := &group.items[i]
+			operands, err := parseExpression(item.Text, group.operator)
+			if err != nil {
+				verr.add("%s: %v", item.QuestionID, err)
+				continue
+			}
+			// Teks adalah yang dilihat anak, jadi operands harus sama persis dengan teks
+			if !equalInts(operands, item.Operands) {
+				verr.add("%s: operands %v do not match the text %q", item.QuestionID, item.Operands, item.Text)
+				continue
+			}
+			fix(item.QuestionID, &item.Answer, evaluate(operands, group.operator))
+		}
+	}
+
+	for i := range g.Substitution {
+		item := &g.Substitution[i]
+		digit, err := legendDigit(item.Legend, item.Symbol)
+		if err != nil {
+			verr.add("%s: %v", item.QuestionID, err)
+			continue
+		}
+		fix(item.QuestionID, &item.Answer, digit)
+	}
+
+	if len(verr.Problems) > 0 {
+		return fixes, verr
+	}
+	return fixes, nil
+}
+
+// parseExpression mengambil operand dari satu-satunya ekspresi di teks soal
+func parseExpression(text, operator string) ([]int, error) {
+	expressions := expressionPattern.FindAllString(text, -1)
+	if len(expressions) != 1 {
+		return nil, fmt.Errorf("text %q must contain exactly one expression", text)
+	}
+	expr := expressions[0]
+
+	for _, r := range expr {
+		if isOperator(r) && normalizeOperator(r) != operator {
+			return nil, fmt.Errorf("text %q uses %q but the subtest operator is %q", text, string(r), operator)
+		}
+	}
+
+	var operands []int
+	for _, n := range numberPattern.FindAllString(expr, -1) {
+		v, err := strconv.Atoi(n)
+		if err != nil {
+			return nil, fmt.Errorf("text %q contains an invalid number %q", text, n)
+		}
+		operands = append(operands, v)
+	}
+	return operands, nil
+}
+
+func isOperator(r rune) bool {
+	return strings.ContainsRune("+xX×*", r)
+}
+
+func normalizeOperator(r rune) string {
+	if r == '+' {
+		return "+"
+	}
+	return "x"
+}
+
+func evaluate(operands []int, operator string) int {
+	result := operands[0]
+	for _, v := range operands[1:] {
+		if operator == "+" {
+			result += v
+		} else {
+			result *= v
+		}
+	}
+	return result
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// legendDigit mencari angka pasangan symbol. Simbol yang muncul lebih dari sekali dianggap ambigu.
+func legendDigit(legend []SymbolDigit, symbol string) (int, error) {
+	found := 0
+	digit := 0
+	for _, pair := range legend {
+		if pair.Symbol == symbol {
+			found++
+			digit = pair.Digit
+		}
+	}
+	switch found {
+	case 0:
+		return 0, fmt.Errorf("symbol %q is not in the legend", symbol)
+	case 1:
+		return digit, nil
+	default:
+		return 0, fmt.Errorf("symbol %q appears %d times in the legend", symbol, found)
+	}
+}
@@ -0,0 +1,96 @@
+package testset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(g *Generated)
+		fixes   []string
+		problem string
+		check   func(t *testing.T, g Generated)
+	}{
+		{name: "correct keys", mutate: func(g *Generated) {}},
+		{
+			name:   "wrong addition key is corrected",
+			mutate: func(g *Generated) { g.Addition[0].Answer = "20" },
+			fixes:  []string{`add_1: answer "20" corrected to "21"`},
+			check: func(t *testing.T, g Generated) {
+				if g.Addition[0].Answer != "21" {
+					t.Errorf("answer = %q, want 21", g.Addition[0].Answer)
+				}
+			},
+		},
+		{
+			name: "multiplication with three operands",
+			mutate: func(g *Generated) {
+				g.Multiplication[0].Text, g.Multiplication[0].Operands, g.Multiplication[0].Answer = "2 × 3 × 4", []int{2, 3, 4}, "9"
+			},
+			fixes: []string{`mult_1: answer "9" corrected to "24"`},
+		},
+		{
+			name:   "dot and stroop keys follow the data",
+			mutate: func(g *Generated) { g.Dot[0].Answer = "4"; g.Stroop[0].Answer = "3" },
+			fixes:  []string{`dot_1: answer "4" corrected to "5"`, `stroop_1: answer "3" corrected to "8"`},
+		},
+		{
+			name:   "substitution key follows the legend",
+			mutate: func(g *Generated) { g.Substitution[0].Answer = "1" },
+			fixes:  []string{`subs_1: answer "1" corrected to "2"`},
+		},
+		{
+			name:    "operands differ from text",
+			mutate:  func(g *Generated) { g.Addition[0].Operands = []int{12, 8} },
+			problem: "add_1: operands [12 8] do not match the text",
+		},
+		{
+			name:    "operator differs from subtest",
+			mutate:  func(g *Generated) { g.Addition[0].Text = "12 x 9 = ?" },
+			problem: `uses "x" but the subtest operator is "+"`,
+		},
+		{
+			name:    "two expressions",
+			mutate:  func(g *Generated) { g.Addition[0].Text = "12 + 9 atau 1 + 1?" },
+			problem: "must contain exactly one expression",
+		},
+		{
+			name:    "symbol missing from legend",
+			mutate:  func(g *Generated) { g.Substitution[0].Symbol = "■" },
+			problem: `symbol "■" is not in the legend`,
+		},
+		{
+			name: "ambiguous legend",
+			mutate: func(g *Generated) {
+				g.Substitution[0].Legend = append(g.Substitution[0].Legend, SymbolDigit{Symbol: "●", Digit: 7})
+			},
+			problem: `symbol "●" appears 2 times in the legend`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := validGenerated()
+			tt.mutate(&g)
+			fixes, err := g.Verify()
+			if tt.problem != "" {
+				var verr *ValidationError
+				if !errors.As(err, &verr) || !strings.Contains(err.Error(), tt.problem) {
+					t.Fatalf("got %v, want ValidationError containing %q", err, tt.problem)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(fixes, "\n") != strings.Join(tt.fixes, "\n") {
+				t.Fatalf("fixes = %q, want %q", fixes, tt.fixes)
+			}
+			if tt.check != nil {
+				tt.check(t, g)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package usage
+
+import (
+	"Dysec/internal/ai"
+	"Dysec/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	PurposeTestStart  = "test_start"
+	PurposeBankWorker = "bank_worker"
+)
+
+// Price adalah harga model dalam USD per satu juta token. Model dicocokkan dengan prefix terpanjang,
+// sehingga "gemini-1.5-flash" juga berlaku untuk "gemini-1.5-flash-002".
+type Price struct {
+	Model                string  `mapstructure:"model"`
+	PromptPerMillion     float64 `mapstructure:"prompt_per_1m"`
+	CompletionPerMillion float64 `mapstructure:"completion_per_1m"`
+}
+
+// Budget adalah batas pemakaian bulanan per user. Nilai 0 berarti tanpa batas.
+type Budget struct {
+	Tokens  int64   `mapstructure:"tokens" json:"tokens"`
+	CostUSD float64 `mapstructure:"cost_usd" json:"cost_usd"`
+}
+
+// Totals adalah jumlah pemakaian dalam satu periode
+type Totals struct {
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// BudgetStatus adalah pemakaian bulan berjalan seorang user dibandingkan budget-nya
+type BudgetStatus struct {
+	Budget      Budget    `json:"budget"`
+	Used        Totals    `json:"used"`
+	PeriodStart time.Time `json:"period_start"`
+	Exceeded    bool      `json:"exceeded"`
+}
+
+// Ledger mencatat setiap panggilan AI ke tabel ai_usages dan menghitung budget bulanan
+type Ledger struct {
+	db          *gorm.DB
+	pricing     []Price
+	budget      Budget
+	roleBudgets map[string]Budget
+}
+
+// NewLedgerFromConfig membaca ai.pricing, ai.budget.monthly, dan ai.budget.roles
+func NewLedgerFromConfig(db *gorm.DB) (*Ledger, error) {
+	l := &Ledger{db: db}
+	if err := viper.UnmarshalKey("ai.pricing", &l.pricing); err != nil {
+		return nil, fmt.Errorf("invalid ai.pricing: %w", err)
+	}
+	if err := viper.UnmarshalKey("ai.budget.monthly", &l.budget); err != nil {
+		return nil, fmt.Errorf("invalid ai.budget.monthly: %w", err)
+	}
+	if err := viper.UnmarshalKey("ai.budget.roles", &l.roleBudgets); err != nil {
+		return nil, fmt.Errorf("invalid ai.budget.roles: %w", err)
+	}
+	return l, nil
+}
+
+// Cost menghitung biaya satu panggilan dari tabel harga. Model tanpa harga dianggap gratis.
+func (l *Ledger) Cost(u ai.Usage) float64 {
+	var best *Price
+	for i, p := range l.pricing {
+		if strings.HasPrefix(u.Model, p.Model) && (best == nil || len(p.Model) > len(best.Model)) {
+			best = &l.pricing[i]
+		}
+	}
+	if best == nil {
+		return 0
+	}
+	return (float64(u.PromptTokens)*best.PromptPerMillion + float64(u.CompletionTokens)*best.CompletionPerMillion) / 1e6
+}
+
+// Record menulis satu baris per panggilan. Kegagalan hanya di-log agar tidak menggagalkan aksi utama.
+func (l *Ledger) Record(userID, testID *uint, purpose string, usages []ai.Usage) {
+	if len(usages) == 0 {
+		return
+	}
+
+	rows := make([]models.AIUsage, 0, len(usages))
+	for _, u := range usages {
+		rows = append(rows, models.AIUsage{
+			UserID:           userID,
+			TestID:           testID,
+			Purpose:          purpose,
+			Provider:         u.Provider,
+			Model:            u.Model,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			TotalTokens:      u.TotalTokens,
+			CostUSD:          l.Cost(u),
+			LatencyMs:        u.Latency.Milliseconds(),
+		})
+	}
+	if err := l.db.Create(&rows).Error; err != nil {
+		log.Printf("ERROR: Could not record AI usage (%s): %v", purpose, err)
+	}
+}
+
+// BudgetFor mengembalikan budget bulanan untuk role, dengan override per role bila ada
+func (l *Ledger) BudgetFor(role string) Budget {
+	if b, ok := l.roleBudgets[role]; ok {
+		return b
+	}
+	return l.budget
+}
+
+// CheckBudget menghitung pemakaian user sejak awal bulan berjalan
+func (l *Ledger) CheckBudget(userID uint, role string) (BudgetStatus, error) {
+	now := time.Now()
+	status := BudgetStatus{
+		Budget:      l.BudgetFor(role),
+		PeriodStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
+	}
+
+	err := l.db.Model(&models.AIUsage{}).
+		Select(totalsColumns).
+		Where("user_id = ? AND created_at >= ?", userID, status.PeriodStart).
+		Scan(&status.Used).Error
+	if err != nil {
+		return status, err
+	}
+
+	status.Exceeded = (status.Budget.Tokens > 0 && status.Used.TotalTokens >= status.Budget.Tokens) ||
+		(status.Budget.CostUSD > 0 && status.Used.CostUSD >= status.Budget.CostUSD)
+	return status, nil
+}
+
+const totalsColumns = "COUNT(*) AS calls, " +
+	"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+	"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+	"COALESCE(SUM(total_tokens), 0) AS total_tokens, " +
+	"COALESCE(SUM(cost_usd), 0) AS cost_usd"
+
+// AggregateRow adalah pemakaian untuk satu kunci pengelompokan (hari, user, atau model)
+type AggregateRow struct {
+	Key string `json:"key"`
+	Totals
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+var ErrInvalidGroup = errors.New("group_by must be one of day, user, model")
+
+var groupExpressions = map[string]string{
+	"day":   "to_char(created_at, 'YYYY-MM-DD')",
+	"user":  "COALESCE(CAST(user_id AS TEXT), 'system')",
+	"model": "provider || '/' || model",
+}
+
+// Aggregate menjumlahkan pemakaian dalam rentang [from, to) yang dikelompokkan per groupBy
+func (l *Ledger) Aggregate(groupBy string, from, to time.Time) ([]AggregateRow, error) {
+	expr, ok := groupExpressions[groupBy]
+	if !ok {
+		return nil, ErrInvalidGroup
+	}
+
+	var rows []AggregateRow
+	err := l.db.Model(&models.AIUsage{}).
+		Select(expr+" AS key, "+totalsColumns+", COALESCE(AVG(latency_ms), 0) AS avg_latency_ms").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group(expr).
+		Order("key asc").
+		Scan(&rows).Error
+	return rows, err
+}